@@ -354,9 +354,9 @@ func (r *Reactor) broadcastHasVoteMessage(ctx context.Context, vote *types.Vote)
 // internal pubsub defined in the consensus state to broadcast them to peers
 // upon receiving.
 func (r *Reactor) subscribeToBroadcastEvents() {
-	err := r.state.evsw.AddListenerForEvent(
+	err := r.state.evsw.AddListenerForEventName(
 		listenerIDConsensus,
-		types.EventNewRoundStepValue,
+		tmevents.EventNewRoundStep,
 		func(ctx context.Context, data tmevents.EventData) error {
 			if err := r.broadcastNewRoundStepMessage(ctx, data.(*cstypes.RoundState)); err != nil {
 				return err
@@ -375,9 +375,9 @@ func (r *Reactor) subscribeToBroadcastEvents() {
 		r.logger.Error("failed to add listener for events", "err", err)
 	}
 
-	err = r.state.evsw.AddListenerForEvent(
+	err = r.state.evsw.AddListenerForEventName(
 		listenerIDConsensus,
-		types.EventValidBlockValue,
+		tmevents.EventValidBlock,
 		func(ctx context.Context, data tmevents.EventData) error {
 			return r.broadcastNewValidBlockMessage(ctx, data.(*cstypes.RoundState))
 		},
@@ -386,9 +386,9 @@ func (r *Reactor) subscribeToBroadcastEvents() {
 		r.logger.Error("failed to add listener for events", "err", err)
 	}
 
-	err = r.state.evsw.AddListenerForEvent(
+	err = r.state.evsw.AddListenerForEventName(
 		listenerIDConsensus,
-		types.EventVoteValue,
+		tmevents.EventVote,
 		func(ctx context.Context, data tmevents.EventData) error {
 			return r.broadcastHasVoteMessage(ctx, data.(*types.Vote))
 		},
@@ -788,7 +788,9 @@ func (cs *State) newStep(ctx context.Context) {
 			cs.logger.Error("failed publishing new round step", "err", err)
 		}
 
-		cs.evsw.FireEvent(ctx, types.EventNewRoundStepValue, &cs.RoundState)
+		if err := cs.evsw.FireEventName(ctx, tmevents.EventNewRoundStep, &cs.RoundState); err != nil {
+			cs.logger.Error("failed firing new round step", "err", err)
+		}
 	}
 }
 
@@ -1634,7 +1636,9 @@ func (cs *State) enterCommit(ctx context.Context, height int64, commitRound int3
 				logger.Error("failed publishing valid block", "err", err)
 			}
 
-			cs.evsw.FireEvent(ctx, types.EventValidBlockValue, &cs.RoundState)
+			if err := cs.evsw.FireEventName(ctx, tmevents.EventValidBlock, &cs.RoundState); err != nil {
+				logger.Error("failed firing valid block", "err", err)
+			}
 		}
 	}
 }
@@ -2102,7 +2106,9 @@ func (cs *State) addVote(
 			return added, err
 		}
 
-		cs.evsw.FireEvent(ctx, types.EventVoteValue, vote)
+		if err := cs.evsw.FireEventName(ctx, tmevents.EventVote, vote); err != nil {
+			return added, err
+		}
 
 		// if we can skip timeoutCommit and have all the votes now,
 		if cs.config.SkipTimeoutCommit && cs.LastCommit.HasAll() {
@@ -2131,7 +2137,9 @@ func (cs *State) addVote(
 	if err := cs.eventBus.PublishEventVote(ctx, types.EventDataVote{Vote: vote}); err != nil {
 		return added, err
 	}
-	cs.evsw.FireEvent(ctx, types.EventVoteValue, vote)
+	if err := cs.evsw.FireEventName(ctx, tmevents.EventVote, vote); err != nil {
+		return added, err
+	}
 
 	switch vote.Type {
 	case tmproto.PrevoteType:
@@ -2185,7 +2193,9 @@ func (cs *State) addVote(
 					cs.ProposalBlockParts = types.NewPartSetFromHeader(blockID.PartSetHeader)
 				}
 
-				cs.evsw.FireEvent(ctx, types.EventValidBlockValue, &cs.RoundState)
+				if err := cs.evsw.FireEventName(ctx, tmevents.EventValidBlock, &cs.RoundState); err != nil {
+					return added, err
+				}
 				if err := cs.eventBus.PublishEventValidBlock(ctx, cs.RoundStateEvent()); err != nil {
 					return added, err
 				}
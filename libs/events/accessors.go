@@ -0,0 +1,41 @@
+package events
+
+// The accessors below assert the type of event data without panicking,
+// e.g. n, ok := AsUint64(data). They match the dynamic type exactly: an int
+// is not reported as a uint64, nor a string as a []byte.
+
+// AsUint64 returns data as a uint64 and reports whether it is one.
+func AsUint64(data EventData) (uint64, bool) {
+	v, ok := data.(uint64)
+	return v, ok
+}
+
+// AsInt64 returns data as an int64 and reports whether it is one.
+func AsInt64(data EventData) (int64, bool) {
+	v, ok := data.(int64)
+	return v, ok
+}
+
+// AsInt returns data as an int and reports whether it is one.
+func AsInt(data EventData) (int, bool) {
+	v, ok := data.(int)
+	return v, ok
+}
+
+// AsString returns data as a string and reports whether it is one.
+func AsString(data EventData) (string, bool) {
+	v, ok := data.(string)
+	return v, ok
+}
+
+// AsBytes returns data as a []byte and reports whether it is one.
+func AsBytes(data EventData) ([]byte, bool) {
+	v, ok := data.([]byte)
+	return v, ok
+}
+
+// AsBool returns data as a bool and reports whether it is one.
+func AsBool(data EventData) (bool, bool) {
+	v, ok := data.(bool)
+	return v, ok
+}
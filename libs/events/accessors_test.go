@@ -0,0 +1,53 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessors(t *testing.T) {
+	n, ok := AsUint64(uint64(7))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), n)
+
+	i64, ok := AsInt64(int64(-7))
+	assert.True(t, ok)
+	assert.Equal(t, int64(-7), i64)
+
+	i, ok := AsInt(7)
+	assert.True(t, ok)
+	assert.Equal(t, 7, i)
+
+	s, ok := AsString("block")
+	assert.True(t, ok)
+	assert.Equal(t, "block", s)
+
+	b, ok := AsBytes([]byte{0xab})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0xab}, b)
+
+	v, ok := AsBool(true)
+	assert.True(t, ok)
+	assert.True(t, v)
+}
+
+func TestAccessorsMismatch(t *testing.T) {
+	for _, data := range []EventData{nil, 7, "7", struct{}{}} {
+		n, ok := AsUint64(data)
+		assert.False(t, ok, "%#v", data)
+		assert.Zero(t, n)
+	}
+
+	_, ok := AsInt64(7)
+	assert.False(t, ok)
+	_, ok = AsInt(uint64(7))
+	assert.False(t, ok)
+	_, ok = AsString([]byte("block"))
+	assert.False(t, ok)
+	b, ok := AsBytes("block")
+	assert.False(t, ok)
+	assert.Nil(t, b)
+	_, ok = AsBool(1)
+	assert.False(t, ok)
+}
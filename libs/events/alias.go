@@ -0,0 +1,64 @@
+package events
+
+func (evsw *eventSwitch) Alias(oldEvent, newEvent string) {
+	if oldEvent == newEvent {
+		return
+	}
+
+	evsw.mtx.Lock()
+	defer evsw.mtx.Unlock()
+
+	for _, e := range evsw.aliases[oldEvent] {
+		if e == newEvent {
+			return
+		}
+	}
+	evsw.aliases[oldEvent] = append(evsw.aliases[oldEvent], newEvent)
+}
+
+// aliasedEvent is an event reached while resolving aliases, and its cell.
+type aliasedEvent struct {
+	event string
+	cell  *eventCell
+}
+
+// aliasedCells returns the cells of event and of every event it is aliased
+// to, directly or not, visiting each event once so that alias cycles
+// terminate. evsw.mtx must be held.
+func (evsw *eventSwitch) aliasedCells(event string) []aliasedEvent {
+	var cells []aliasedEvent
+	visited := map[string]bool{event: true}
+	queue := []string{event}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		if cell := evsw.eventCells[e]; cell != nil {
+			cells = append(cells, aliasedEvent{event: e, cell: cell})
+		}
+		for _, next := range evsw.aliases[e] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return cells
+}
+
+// aliasedCallbacks returns the callbacks of cells in order, skipping
+// listeners already reached through an earlier cell.
+func aliasedCallbacks(cells []aliasedEvent) []listenerCallback {
+	var callbacks []listenerCallback
+	seen := make(map[string]bool)
+	for _, ae := range cells {
+		for _, lc := range ae.cell.Callbacks() {
+			if seen[lc.listenerID] {
+				continue
+			}
+			seen[lc.listenerID] = true
+			lc.event = ae.event
+			callbacks = append(callbacks, lc)
+		}
+	}
+	return callbacks
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAlias(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received []EventData
+	require.NoError(t, evsw.AddListenerForEvent("new", "event/new",
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+
+	evsw.Alias("event/old", "event/new")
+	evsw.FireEvent(ctx, "event/old", "from old")
+	evsw.FireEvent(ctx, "event/new", "from new")
+	assert.Equal(t, []EventData{"from old", "from new"}, received)
+}
+
+func TestAliasChainAndCycle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	counts := make(map[string]int)
+	for _, event := range []string{"a", "b", "c"} {
+		event := event
+		require.NoError(t, evsw.AddListenerForEvent(event, event,
+			func(context.Context, EventData) error {
+				counts[event]++
+				return nil
+			}))
+	}
+	// subscribed to two events reached by the same fire
+	both := func(context.Context, EventData) error {
+		counts["both"]++
+		return nil
+	}
+	require.NoError(t, evsw.AddListenerForEvent("both", "a", both))
+	require.NoError(t, evsw.AddListenerForEvent("both", "c", both))
+
+	evsw.Alias("a", "b")
+	evsw.Alias("b", "c")
+	evsw.Alias("c", "a")
+	evsw.Alias("a", "b") // duplicate
+	evsw.Alias("a", "a") // self
+
+	evsw.FireEvent(ctx, "b", nil)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1, "both": 1}, counts)
+}
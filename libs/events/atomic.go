@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// EventPair is an event along with the data to fire it with, see
+// FireAtomic.
+type EventPair struct {
+	Event string
+	Data  EventData
+}
+
+// FireGroup identifies the fires of one call to FireAtomic.
+type FireGroup struct {
+	// ID is shared by all the fires of the call and unique to the switch.
+	ID uint64
+	// Index is the position of the fire among those of the call, and Len
+	// their number.
+	Index int
+	Len   int
+}
+
+// fireGroupKey is the context key of the FireGroup attached by FireAtomic.
+type fireGroupKey struct{}
+
+// FireGroupFromContext returns the group of the fire whose callback
+// received ctx, if it was made by FireAtomic.
+func FireGroupFromContext(ctx context.Context) (FireGroup, bool) {
+	group, ok := ctx.Value(fireGroupKey{}).(FireGroup)
+	return group, ok
+}
+
+func (evsw *eventSwitch) FireAtomic(ctx context.Context, pairs []EventPair) uint64 {
+	if len(pairs) == 0 || !evsw.fires.begin() {
+		return 0
+	}
+	defer evsw.fires.end()
+
+	id := atomic.AddUint64(&evsw.groupSeq, 1)
+	for i, pair := range pairs {
+		group := FireGroup{ID: id, Index: i, Len: len(pairs)}
+		evsw.fire(context.WithValue(ctx, fireGroupKey{}, group), pair.Event, pair.Data)
+	}
+	return id
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireAtomic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	type received struct {
+		event string
+		data  EventData
+		group FireGroup
+	}
+	var got []received
+	record := func(event string) EventCallback {
+		return func(ctx context.Context, data EventData) error {
+			group, ok := FireGroupFromContext(ctx)
+			assert.True(t, ok)
+			got = append(got, received{event, data, group})
+			return nil
+		}
+	}
+	require.NoError(t, evsw.AddListenerForEvent("listener", "lock", record("lock")))
+	require.NoError(t, evsw.AddListenerForEvent("listener", "commit", record("commit")))
+
+	id := evsw.FireAtomic(ctx, []EventPair{
+		{Event: "lock", Data: 1},
+		{Event: "unlisted", Data: 2},
+		{Event: "commit", Data: 3},
+	})
+	require.NotZero(t, id)
+	assert.Equal(t, []received{
+		{"lock", 1, FireGroup{ID: id, Index: 0, Len: 3}},
+		{"commit", 3, FireGroup{ID: id, Index: 2, Len: 3}},
+	}, got)
+
+	assert.NotEqual(t, id, evsw.FireAtomic(ctx, []EventPair{{Event: "lock"}}))
+	assert.Zero(t, evsw.FireAtomic(ctx, nil))
+}
+
+func TestFireGroupFromContext(t *testing.T) {
+	_, ok := FireGroupFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidBatch is returned by AddBatchingListener when the window or the
+// maximum batch size is not positive.
+var ErrInvalidBatch = errors.New("batch window and size must be positive")
+
+// BatchCallback is the callback of a batching listener, see
+// AddBatchingListener. It receives the data of the fires of a batch, in the
+// order they were fired.
+type BatchCallback func(ctx context.Context, batch []EventData) error
+
+// batchingListener accumulates the data fired for it and hands it to its
+// callback in batches from a goroutine of its own.
+type batchingListener struct {
+	listenerID string
+	event      string
+	clock      Clock
+	window     time.Duration
+	maxBatch   int
+	cb         BatchCallback
+
+	// in holds at most one batch worth of data; fires block while it is
+	// full, so a slow callback slows down the producers instead of letting
+	// the backlog grow without bound.
+	in chan EventData
+
+	done      chan struct{}
+	closeOnce sync.Once
+	// exited is closed when run returns.
+	exited chan struct{}
+}
+
+func (evsw *eventSwitch) AddBatchingListener(
+	listenerID, event string,
+	window time.Duration,
+	maxBatch int,
+	cb BatchCallback,
+) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+	if window <= 0 || maxBatch <= 0 {
+		return ErrInvalidBatch
+	}
+
+	bl := &batchingListener{
+		listenerID: listenerID,
+		event:      event,
+		clock:      evsw.clock,
+		window:     window,
+		maxBatch:   maxBatch,
+		cb:         cb,
+		in:         make(chan EventData, maxBatch),
+		done:       make(chan struct{}),
+		exited:     make(chan struct{}),
+	}
+	if err := evsw.addListener(listenerID, event, bl.send, subState{bl: bl}); err != nil {
+		return err
+	}
+	go evsw.runBatching(evsw.Context(), bl)
+	return nil
+}
+
+// send queues data for the next batch, waiting for room if the listener is
+// behind.
+func (bl *batchingListener) send(ctx context.Context, data EventData) error {
+	select {
+	case bl.in <- data:
+		return nil
+	case <-bl.done:
+	case <-bl.exited:
+	case <-ctx.Done():
+	}
+	return errEventDropped
+}
+
+// close stops the listener once the data already queued has been delivered.
+func (bl *batchingListener) close() {
+	bl.closeOnce.Do(func() { close(bl.done) })
+}
+
+// runBatching collects batches for bl and delivers them until the listener
+// is removed or ctx is done. On removal the data queued so far is delivered
+// as a final batch; once ctx is done it is discarded.
+func (evsw *eventSwitch) runBatching(ctx context.Context, bl *batchingListener) {
+	defer close(bl.exited)
+
+	for {
+		var batch []EventData
+		select {
+		case data := <-bl.in:
+			batch = append(batch, data)
+		case <-bl.done:
+			evsw.deliverBatch(ctx, bl, drainBatch(bl.in, nil))
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		timer := bl.clock.NewTimer(bl.window)
+		closed := false
+	collect:
+		for len(batch) < bl.maxBatch {
+			select {
+			case data := <-bl.in:
+				batch = append(batch, data)
+			case <-timer.C():
+				break collect
+			case <-bl.done:
+				batch = drainBatch(bl.in, batch)
+				closed = true
+				break collect
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		timer.Stop()
+
+		evsw.deliverBatch(ctx, bl, batch)
+		if closed {
+			return
+		}
+	}
+}
+
+// drainBatch appends the data queued in ch to batch without blocking.
+func drainBatch(ch <-chan EventData, batch []EventData) []EventData {
+	for {
+		select {
+		case data := <-ch:
+			batch = append(batch, data)
+		default:
+			return batch
+		}
+	}
+}
+
+// deliverBatch hands batch to the callback of bl, split into batches of at
+// most maxBatch elements.
+func (evsw *eventSwitch) deliverBatch(ctx context.Context, bl *batchingListener, batch []EventData) {
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > bl.maxBatch {
+			n = bl.maxBatch
+		}
+		if err := bl.cb(ctx, batch[:n]); err != nil {
+			evsw.handleError(bl.listenerID, bl.event, err)
+		}
+		batch = batch[n:]
+	}
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddBatchingListenerInvalid(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	cb := func(context.Context, []EventData) error { return nil }
+
+	require.ErrorIs(t, evsw.AddBatchingListener("listener", "event", 0, 1, cb), ErrInvalidBatch)
+	require.ErrorIs(t, evsw.AddBatchingListener("listener", "event", time.Second, 0, cb), ErrInvalidBatch)
+	assert.Empty(t, evsw.Listeners("event"))
+}
+
+func TestAddBatchingListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	batches := make(chan []EventData, 10)
+	require.NoError(t, evsw.AddBatchingListener("listener", "event", time.Second, 3,
+		func(_ context.Context, batch []EventData) error {
+			batches <- append([]EventData(nil), batch...)
+			return nil
+		}))
+
+	// a full batch is delivered without waiting for the window
+	for i := 1; i <= 3; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	assert.Equal(t, []EventData{1, 2, 3}, <-batches)
+
+	// a partial batch is delivered once the window elapses
+	evsw.FireEvent(ctx, "event", 4)
+	evsw.FireEvent(ctx, "event", 5)
+	clock.waitForTimers(t, 2) // the lag monitor holds the other timer
+	select {
+	case batch := <-batches:
+		t.Fatalf("unexpected batch %v before the window elapsed", batch)
+	default:
+	}
+	clock.Advance(time.Second)
+	assert.Equal(t, []EventData{4, 5}, <-batches)
+
+	// removing the listener flushes the data fired so far
+	evsw.FireEvent(ctx, "event", 6)
+	evsw.RemoveListener("listener")
+	assert.Equal(t, []EventData{6}, <-batches)
+
+	evsw.FireEvent(ctx, "event", 7)
+	select {
+	case batch := <-batches:
+		t.Fatalf("unexpected batch %v after removal", batch)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestAddBatchingListenerConcurrentRemove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger()).(*eventSwitch)
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	noop := func(context.Context, []EventData) error { return nil }
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		listenerID := fmt.Sprintf("listener%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				_ = evsw.AddBatchingListener(listenerID, "event", time.Second, 10, noop)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				evsw.RemoveListener(listenerID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// a batching listener is kept exactly as long as its subscription, so
+	// that removing it stops its goroutine
+	for i := 0; i < 4; i++ {
+		key := subKey{listenerID: fmt.Sprintf("listener%d", i), event: "event"}
+		evsw.mtx.RLock()
+		_, batching := evsw.batching[key]
+		evsw.mtx.RUnlock()
+		assert.Equal(t, evsw.isSubscribed(key.listenerID, key.event), batching, key.listenerID)
+	}
+}
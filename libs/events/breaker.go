@@ -0,0 +1,149 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker of a listener, see
+// WithCircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed is the normal state: the callback is invoked for every
+	// fire.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means the callback failed too many times in a row and is
+	// not invoked until the cooldown has elapsed.
+	BreakerOpen
+	// BreakerHalfOpen means the cooldown has elapsed and the next invocation
+	// decides whether the breaker closes again or reopens.
+	BreakerHalfOpen
+)
+
+// String implements the fmt.Stringer interface.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breakers holds the circuit breaker of each listener.
+type breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mtx sync.Mutex
+	m   map[string]*breaker
+}
+
+type breaker struct {
+	state    BreakerState
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the breaker last opened
+	probing  bool      // a half-open trial invocation is running
+}
+
+func newBreakers(threshold int, cooldown time.Duration) *breakers {
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		m:         make(map[string]*breaker),
+	}
+}
+
+// allow reports whether the callback of the listener may be invoked at now.
+// Once the cooldown of an open breaker has elapsed, a single trial
+// invocation is allowed at a time.
+func (bs *breakers) allow(listenerID string, now time.Time) bool {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+
+	b := bs.m[listenerID]
+	if b == nil {
+		return true
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < bs.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// record records the outcome of an invocation allowed by allow.
+func (bs *breakers) record(listenerID string, failed bool, now time.Time) {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+
+	b := bs.m[listenerID]
+	if b == nil {
+		if !failed {
+			return
+		}
+		b = &breaker{}
+		bs.m[listenerID] = b
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= bs.threshold {
+			b.state = BreakerOpen
+			b.openedAt = now
+		}
+	case BreakerHalfOpen:
+		b.probing = false
+		if failed {
+			b.state = BreakerOpen
+			b.openedAt = now
+			return
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+	}
+}
+
+func (bs *breakers) remove(listenerID string) {
+	bs.mtx.Lock()
+	delete(bs.m, listenerID)
+	bs.mtx.Unlock()
+}
+
+// states returns the state at now of every breaker that is not closed. An
+// open breaker whose cooldown has elapsed is reported as half-open.
+func (bs *breakers) states(now time.Time) map[string]BreakerState {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+
+	states := make(map[string]BreakerState)
+	for listenerID, b := range bs.m {
+		switch {
+		case b.state == BreakerOpen && now.Sub(b.openedAt) >= bs.cooldown:
+			states[listenerID] = BreakerHalfOpen
+		case b.state != BreakerClosed:
+			states[listenerID] = b.state
+		}
+	}
+	return states
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithCircuitBreaker(3, time.Minute))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var calls int
+	fail := true
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			calls++
+			if fail {
+				return errors.New("downstream unavailable")
+			}
+			return nil
+		}))
+
+	// closed: every fire is delivered until the threshold is reached
+	for i := 0; i < 3; i++ {
+		delivered, skipped := evsw.FireEventCounted(ctx, "event", nil)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, 0, skipped)
+	}
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, map[string]BreakerState{"listener": BreakerOpen}, evsw.Stats().Breakers)
+
+	// open: fires are skipped during the cooldown
+	delivered, skipped := evsw.FireEventCounted(ctx, "event", nil)
+	assert.Equal(t, 0, delivered)
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, 3, calls)
+
+	// half-open: a failed trial reopens the breaker
+	clock.Advance(time.Minute)
+	assert.Equal(t, map[string]BreakerState{"listener": BreakerHalfOpen}, evsw.Stats().Breakers)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, map[string]BreakerState{"listener": BreakerOpen}, evsw.Stats().Breakers)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, 4, calls)
+
+	// a successful trial closes it again
+	clock.Advance(time.Minute)
+	fail = false
+	evsw.FireEvent(ctx, "event", nil)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, 6, calls)
+	assert.Empty(t, evsw.Stats().Breakers)
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithCircuitBreaker(2, time.Minute))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var calls int
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			calls++
+			if calls%2 == 1 {
+				return errors.New("flaky")
+			}
+			return nil
+		}))
+
+	// failures that are not consecutive never open the breaker
+	for i := 0; i < 6; i++ {
+		evsw.FireEvent(ctx, "event", nil)
+	}
+	assert.Equal(t, 6, calls)
+	assert.Empty(t, evsw.Stats().Breakers)
+	assert.Nil(t, NewEventSwitch(log.TestingLogger()).Stats().Breakers)
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", BreakerClosed.String())
+	assert.Equal(t, "open", BreakerOpen.String())
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Reducer folds the data of a fire into the state of a CacheListener and
+// returns the new state. It must not modify state in place if other
+// goroutines may hold it from an earlier Get.
+type Reducer func(state interface{}, data EventData) interface{}
+
+// CacheListener maintains a view derived from the fires of an event and
+// serves it to concurrent readers.
+type CacheListener struct {
+	reduce Reducer
+
+	mtx   sync.RWMutex
+	state interface{}
+}
+
+// NewCacheListener subscribes listenerID to event on evsw and returns a
+// CacheListener whose state starts as initial and is updated with reduce on
+// every fire. Reductions are serialized, so reduce needs no locking of its
+// own. Remove the listener from evsw to stop the updates.
+func NewCacheListener(
+	evsw EventSwitch,
+	listenerID, event string,
+	initial interface{},
+	reduce Reducer,
+) (*CacheListener, error) {
+	if reduce == nil {
+		return nil, ErrNilCallback
+	}
+
+	c := &CacheListener{reduce: reduce, state: initial}
+	if err := evsw.AddListenerForEvent(listenerID, event, c.update); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *CacheListener) update(_ context.Context, data EventData) error {
+	c.mtx.Lock()
+	c.state = c.reduce(c.state, data)
+	c.mtx.Unlock()
+	return nil
+}
+
+// Get returns the current state.
+func (c *CacheListener) Get() interface{} {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.state
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestCacheListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	_, err := NewCacheListener(evsw, "sum", "event", 0, nil)
+	require.ErrorIs(t, err, ErrNilCallback)
+
+	sum, err := NewCacheListener(evsw, "sum", "event", 0,
+		func(state interface{}, data EventData) interface{} {
+			return state.(int) + data.(int)
+		})
+	require.NoError(t, err)
+	assert.Equal(t, 0, sum.Get())
+
+	const fires = 1000
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= fires; i++ {
+			evsw.FireEvent(ctx, "event", i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// reads see the sums growing monotonically while the fires go on
+		last := 0
+		for last < fires*(fires+1)/2 {
+			cur := sum.Get().(int)
+			assert.GreaterOrEqual(t, cur, last)
+			last = cur
+		}
+	}()
+	wg.Wait()
+	assert.Equal(t, fires*(fires+1)/2, sum.Get())
+
+	evsw.RemoveListener("sum")
+	evsw.FireEvent(ctx, "event", 1)
+	assert.Equal(t, fires*(fires+1)/2, sum.Get())
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentFiresLen is the number of fires whose caller is retained for Stats.
+const recentFiresLen = 16
+
+// FireRecord describes a fire recorded by a switch created with
+// WithCallerInfo.
+type FireRecord struct {
+	Event string
+	// Caller is the file:line of the code that fired the event.
+	Caller string
+	// Time is when the event was fired, or the time given to FireEventAt.
+	Time time.Time
+}
+
+// switchMethodPrefix is the prefix of the function names of the methods of
+// eventSwitch, which are skipped when looking for the caller of a fire.
+const switchMethodPrefix = "github.com/tendermint/tendermint/libs/events.(*eventSwitch)."
+
+// fireCaller returns the file:line of the innermost caller outside the
+// switch.
+func fireCaller() string {
+	pcs := make([]uintptr, 16)
+	// skip runtime.Callers, fireCaller and its caller
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, switchMethodPrefix) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// recentFires is a ring of the most recent FireRecords.
+type recentFires struct {
+	mtx    sync.Mutex
+	ring   [recentFiresLen]FireRecord
+	next   int
+	filled bool
+}
+
+func (r *recentFires) add(rec FireRecord) {
+	r.mtx.Lock()
+	r.ring[r.next] = rec
+	r.next = (r.next + 1) % recentFiresLen
+	r.filled = r.filled || r.next == 0
+	r.mtx.Unlock()
+}
+
+// records returns the retained records, oldest first.
+func (r *recentFires) records() []FireRecord {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.filled {
+		return append([]FireRecord(nil), r.ring[:r.next]...)
+	}
+	return append(append([]FireRecord(nil), r.ring[r.next:]...), r.ring[:r.next]...)
+}
+
+// recordCaller logs the fire of event along with its caller and retains it
+// for Stats.
+func (evsw *eventSwitch) recordCaller(ctx context.Context, event string) {
+	rec := FireRecord{Event: event, Caller: fireCaller(), Time: evsw.fireTime(ctx)}
+	evsw.logger.Debug("firing event", "event", event, "caller", rec.Caller)
+	evsw.callers.add(rec)
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestWithCallerInfo(t *testing.T) {
+	ctx := context.Background()
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithCallerInfo())
+
+	_, file, line, ok := runtime.Caller(0)
+	require.True(t, ok)
+	evsw.FireEvent(ctx, "direct", nil)
+	evsw.FireEventName(ctx, EventVote, nil)
+
+	fires := evsw.Stats().RecentFires
+	require.Len(t, fires, 2)
+	assert.Equal(t, FireRecord{Event: "direct", Caller: fmt.Sprintf("%s:%d", file, line+2), Time: clock.Now()}, fires[0])
+	// wrappers of FireEvent report their own caller
+	assert.Equal(t, "Vote", fires[1].Event)
+	assert.Equal(t, fmt.Sprintf("%s:%d", file, line+3), fires[1].Caller)
+
+	// only the most recent fires are retained
+	for i := 0; i < recentFiresLen+2; i++ {
+		evsw.FireEvent(ctx, fmt.Sprint(i), nil)
+	}
+	fires = evsw.Stats().RecentFires
+	require.Len(t, fires, recentFiresLen)
+	assert.Equal(t, "2", fires[0].Event)
+	assert.Equal(t, fmt.Sprint(recentFiresLen+1), fires[recentFiresLen-1].Event)
+
+	assert.Nil(t, NewEventSwitch(log.TestingLogger()).Stats().RecentFires)
+}
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrInvalidBufferSize is returned by SubscribeChan if the requested buffer
+// size is negative.
+var ErrInvalidBufferSize = errors.New("channel buffer size must not be negative")
+
+// errEventDropped is returned by the callback of a channel subscription that
+// discarded the event instead of buffering it.
+var errEventDropped = errors.New("event dropped")
+
+// OverflowPolicy determines what a channel subscription does with an event
+// when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block waits until the consumer makes room in the buffer, the context
+	// passed to FireEvent is done (which drops the event), or the
+	// subscription is removed. Use it for
+	// consumers that must not miss events; a slow consumer slows down every
+	// fire of the event.
+	Block OverflowPolicy = iota
+	// DropOldest discards the oldest buffered events to make room for the new
+	// one. With an unbuffered channel it behaves like DropNewest.
+	DropOldest
+	// DropNewest discards the new event and keeps the buffer as it is.
+	DropNewest
+)
+
+// String implements the fmt.Stringer interface.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop-oldest"
+	case DropNewest:
+		return "drop-newest"
+	default:
+		return "unknown"
+	}
+}
+
+// ChanOptions configures a channel subscription created by SubscribeChan.
+type ChanOptions struct {
+	// BufferSize is the capacity of the subscription channel.
+	BufferSize int
+	// OnFull selects what happens to an event when the buffer is full.
+	OnFull OverflowPolicy
+	// OnDrain, if set, is called once the subscription is removed with the
+	// events still buffered in the channel, oldest first, which the
+	// consumer then no longer receives; it is called with none if the
+	// buffer was empty. It runs on the goroutine removing the
+	// subscription. Without it, the buffered events are left in the closed
+	// channel, for the consumer to read or discard.
+	OnDrain func([]EventData)
+}
+
+// chanSub delivers the events of a single (listener, event) pair to a channel.
+type chanSub struct {
+	policy  OverflowPolicy
+	onDrain func([]EventData)
+
+	// mtx is held for reading by senders and for writing by close, so the
+	// channel is never closed while a send is in progress.
+	mtx    sync.RWMutex
+	ch     chan EventData
+	closed bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+
+	dropped   uint64 // atomic
+	highWater int64  // atomic
+	stats     *switchStats
+
+	// sampling state, owned by the lag monitor
+	fullSamples int
+	lagging     bool
+}
+
+func newChanSub(opts ChanOptions, stats *switchStats) *chanSub {
+	return &chanSub{
+		policy:  opts.OnFull,
+		onDrain: opts.OnDrain,
+		stats:   stats,
+		ch:      make(chan EventData, opts.BufferSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// send delivers data according to the overflow policy of the subscription.
+func (sub *chanSub) send(ctx context.Context, data EventData) error {
+	sub.mtx.RLock()
+	defer sub.mtx.RUnlock()
+
+	if sub.closed {
+		return nil
+	}
+
+	switch {
+	case sub.policy == DropNewest, sub.policy == DropOldest && cap(sub.ch) == 0:
+		select {
+		case sub.ch <- data:
+			sub.recordLen()
+		default:
+			sub.drop()
+			return errEventDropped
+		}
+	case sub.policy == DropOldest:
+		for {
+			select {
+			case sub.ch <- data:
+				sub.recordLen()
+				return nil
+			default:
+			}
+			select {
+			case <-sub.ch:
+				sub.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case sub.ch <- data:
+			sub.recordLen()
+		case <-sub.done:
+		case <-ctx.Done():
+			sub.drop()
+			return errEventDropped
+		}
+	}
+	return nil
+}
+
+// close closes the subscription channel, and drains it to the OnDrain
+// callback if any. Blocked senders are released first.
+func (sub *chanSub) close() {
+	sub.closeOnce.Do(func() {
+		close(sub.done)
+
+		sub.mtx.Lock()
+		sub.closed = true
+		close(sub.ch)
+		sub.mtx.Unlock()
+
+		if sub.onDrain != nil {
+			var drained []EventData
+			for data := range sub.ch {
+				drained = append(drained, data)
+			}
+			sub.onDrain(drained)
+		}
+	})
+}
+
+// recordLen updates the high-water mark of the buffer after a send.
+func (sub *chanSub) recordLen() {
+	n := int64(len(sub.ch))
+	for {
+		highWater := atomic.LoadInt64(&sub.highWater)
+		if n <= highWater || atomic.CompareAndSwapInt64(&sub.highWater, highWater, n) {
+			return
+		}
+	}
+}
+
+// drop records an event discarded by the overflow policy.
+func (sub *chanSub) drop() {
+	atomic.AddUint64(&sub.dropped, 1)
+	sub.stats.recordDrop()
+}
+
+// Dropped returns the number of events discarded by the overflow policy.
+func (sub *chanSub) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// SubscribeChan subscribes listenerID to event and returns a channel on which
+// the fired data is delivered, buffered and handled on overflow as described
+// by opts. The channel is closed when the subscription is removed, either by
+// RemoveListenerForEvent or RemoveListener, or replaced by another
+// subscription of the same listener to the same event.
+func (evsw *eventSwitch) SubscribeChan(listenerID, event string, opts ChanOptions) (<-chan EventData, error) {
+	if opts.BufferSize < 0 {
+		return nil, ErrInvalidBufferSize
+	}
+
+	sub := newChanSub(opts, &evsw.stats)
+	if err := evsw.addListener(listenerID, event, sub.send, subState{sub: sub}); err != nil {
+		return nil, err
+	}
+	return sub.ch, nil
+}
+
+func (evsw *eventSwitch) RecvEvent(ctx context.Context, ch <-chan EventData) (EventData, bool, error) {
+	// Prefer events already buffered over reporting the end of the wait.
+	select {
+	case data, ok := <-ch:
+		return data, ok, nil
+	default:
+	}
+
+	select {
+	case data, ok := <-ch:
+		return data, ok, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case <-evsw.Done():
+		return nil, false, ErrShutDown
+	}
+}
@@ -0,0 +1,248 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestSubscribeChanInvalidBufferSize(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+
+	_, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: -1})
+	require.ErrorIs(t, err, ErrInvalidBufferSize)
+}
+
+func TestSubscribeChanDropNewest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ch, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 3, OnFull: DropNewest})
+	require.NoError(t, err)
+
+	for i := uint64(1); i <= 5; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	evsw.RemoveListener("listener")
+
+	assert.Equal(t, []EventData{uint64(1), uint64(2), uint64(3)}, drainChan(ch))
+}
+
+func TestSubscribeChanDropOldest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ch, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 3, OnFull: DropOldest})
+	require.NoError(t, err)
+
+	for i := uint64(1); i <= 5; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	evsw.RemoveListenerForEvent("event", "listener")
+
+	assert.Equal(t, []EventData{uint64(3), uint64(4), uint64(5)}, drainChan(ch))
+}
+
+func TestSubscribeChanBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ch, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 1, OnFull: Block})
+	require.NoError(t, err)
+
+	evsw.FireEvent(ctx, "event", uint64(1))
+
+	fired := make(chan struct{})
+	go func() {
+		defer close(fired)
+		evsw.FireEvent(ctx, "event", uint64(2))
+	}()
+
+	select {
+	case <-fired:
+		t.Fatal("FireEvent should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, uint64(1), <-ch)
+	<-fired
+	assert.Equal(t, uint64(2), <-ch)
+
+	// a blocked fire is released when its context is done
+	evsw.FireEvent(ctx, "event", uint64(3))
+	fireCtx, fireCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer fireCancel()
+	evsw.FireEvent(fireCtx, "event", uint64(4))
+
+	// and when the subscription is removed
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		evsw.RemoveListener("listener")
+	}()
+	evsw.FireEvent(ctx, "event", uint64(5))
+
+	assert.Equal(t, []EventData{uint64(3)}, drainChan(ch))
+}
+
+func TestSubscribeChanIndependentPolicies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	debug, err := evsw.SubscribeChan("debug", "event", ChanOptions{BufferSize: 1, OnFull: DropOldest})
+	require.NoError(t, err)
+	critical, err := evsw.SubscribeChan("critical", "event", ChanOptions{BufferSize: 10, OnFull: Block})
+	require.NoError(t, err)
+
+	for i := uint64(1); i <= 10; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	evsw.RemoveListener("debug")
+	evsw.RemoveListener("critical")
+
+	assert.Equal(t, []EventData{uint64(10)}, drainChan(debug))
+	assert.Len(t, drainChan(critical), 10)
+}
+
+func TestSubscribeChanReplaced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	first, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+	second, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+
+	_, ok := <-first
+	assert.False(t, ok, "replaced subscription should be closed")
+
+	evsw.FireEvent(ctx, "event", "data")
+	assert.Equal(t, "data", <-second)
+}
+
+func TestSubscribeChanOnDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var drained [][]EventData
+	ch, err := evsw.SubscribeChan("drained", "event", ChanOptions{
+		BufferSize: 4,
+		OnDrain:    func(events []EventData) { drained = append(drained, events) },
+	})
+	require.NoError(t, err)
+	kept, err := evsw.SubscribeChan("kept", "event", ChanOptions{BufferSize: 4})
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	assert.Equal(t, 1, <-ch)
+
+	evsw.RemoveListener("drained")
+	assert.Equal(t, [][]EventData{{2, 3}}, drained)
+	assert.Empty(t, drainChan(ch), "drained events are not received")
+
+	// by default the buffered events are left in the closed channel
+	evsw.RemoveListener("kept")
+	assert.Equal(t, []EventData{1, 2, 3}, drainChan(kept))
+
+	// an empty buffer is drained too
+	_, err = evsw.SubscribeChan("empty", "event", ChanOptions{
+		BufferSize: 4,
+		OnDrain:    func(events []EventData) { drained = append(drained, events) },
+	})
+	require.NoError(t, err)
+	evsw.RemoveListenerForEvent("event", "empty")
+	require.Len(t, drained, 2)
+	assert.Empty(t, drained[1])
+}
+
+// drainChan reads ch until it is closed and returns everything it received.
+func drainChan(ch <-chan EventData) []EventData {
+	var out []EventData
+	for data := range ch {
+		out = append(out, data)
+	}
+	return out
+}
+
+func TestRecvEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ch, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+
+	evsw.FireEvent(ctx, "event", "data")
+	data, ok, err := evsw.RecvEvent(ctx, ch)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "data", data)
+
+	recvCtx, recvCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer recvCancel()
+	_, ok, err = evsw.RecvEvent(recvCtx, ch)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, ok)
+
+	evsw.RemoveListenerForEvent("event", "listener")
+	_, ok, err = evsw.RecvEvent(ctx, ch)
+	require.NoError(t, err)
+	assert.False(t, ok, "the channel is closed")
+}
+
+func TestRecvEventSwitchStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	ch, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+	evsw.FireEvent(ctx, "event", "buffered")
+
+	cancel()
+	evsw.Wait()
+
+	data, ok, err := evsw.RecvEvent(context.Background(), ch)
+	require.NoError(t, err, "buffered events are received first")
+	assert.True(t, ok)
+	assert.Equal(t, "buffered", data)
+
+	_, ok, err = evsw.RecvEvent(context.Background(), ch)
+	assert.ErrorIs(t, err, ErrShutDown)
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// NewChildEventSwitch creates an EventSwitch whose fires, once delivered to
+// its own listeners, bubble up to the listeners of parent for the same
+// event. The child is otherwise independent of parent: it has its own
+// listeners and options and must be started and stopped on its own.
+//
+// Every fire method bubbles, FireEventParallel, FireEventAwait,
+// FireEventFirstSuccess and FireEventCounted included, once the child's
+// listeners returned; FireEventAwait only waits for the awaited one. Only the fires the child admits bubble: those rejected
+// because their event is disabled on the child, their data is oversized or
+// the child's fire interceptor dropped them, as well as those a transformer
+// of the child failed, do not.
+//
+// Switches may be chained into a hierarchy. Should a chain loop back, for
+// instance because a parent is also wired as a child of its own child, a
+// fire bubbles through every switch of the loop once and stops when it
+// would reach a switch it already went through.
+func NewChildEventSwitch(logger log.Logger, parent EventSwitch, opts ...Option) EventSwitch {
+	evsw := NewEventSwitch(logger, opts...).(*eventSwitch)
+	evsw.parent = parent
+	return evsw
+}
+
+// bubbleKey is the context key of the switches a bubbling fire went through.
+type bubbleKey struct{}
+
+// bubble fires event on the parent of evsw, unless the fire already went
+// through the parent.
+func (evsw *eventSwitch) bubble(ctx context.Context, event string, data EventData) {
+	visited, _ := ctx.Value(bubbleKey{}).([]Fireable)
+	self := false
+	for _, sw := range visited {
+		if sw == evsw.parent {
+			return
+		}
+		self = self || sw == evsw
+	}
+
+	// Copy rather than append in place, since ctx may be shared by other
+	// fires.
+	next := make([]Fireable, 0, len(visited)+2)
+	next = append(next, visited...)
+	if !self {
+		next = append(next, evsw)
+	}
+	next = append(next, evsw.parent)
+
+	_ = evsw.parent.FireEvent(context.WithValue(ctx, bubbleKey{}, next), event, data)
+}
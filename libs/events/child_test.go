@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestChildEventSwitch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parent := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, parent.Start(ctx))
+	t.Cleanup(parent.Wait)
+	child := NewChildEventSwitch(log.TestingLogger(), parent)
+	require.NoError(t, child.Start(ctx))
+	t.Cleanup(child.Wait)
+
+	var order []string
+	record := func(name string) EventCallback {
+		return func(_ context.Context, data EventData) error {
+			order = append(order, name+":"+data.(string))
+			return nil
+		}
+	}
+	require.NoError(t, parent.AddListenerForEvent("global", "event", record("parent")))
+	require.NoError(t, child.AddListenerForEvent("scoped", "event", record("child")))
+
+	// child fires reach the child listeners first, then the parent ones
+	child.FireEvent(ctx, "event", "a")
+	assert.Equal(t, []string{"child:a", "parent:a"}, order)
+
+	// parent fires stay on the parent
+	order = nil
+	parent.FireEvent(ctx, "event", "b")
+	assert.Equal(t, []string{"parent:b"}, order)
+
+	// fires of an event disabled on the child do not bubble
+	order = nil
+	child.DisableEvent("event")
+	child.FireEvent(ctx, "event", "c")
+	assert.Empty(t, order)
+}
+
+func TestChildEventSwitchRejectedFires(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parent := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, parent.Start(ctx))
+	t.Cleanup(parent.Wait)
+	child := NewChildEventSwitch(log.TestingLogger(), parent,
+		WithFireInterceptor(func(_ string, data EventData) (bool, time.Duration) {
+			return data != "intercepted", 0
+		}))
+	require.NoError(t, child.Start(ctx))
+	t.Cleanup(child.Wait)
+	child.AddTransformer("event", func(data EventData) (EventData, error) {
+		if data == "invalid" {
+			return nil, errors.New("cannot enrich")
+		}
+		return data, nil
+	})
+
+	var received []EventData
+	require.NoError(t, parent.AddListenerForEvent("global", "event",
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+
+	// neither fires the child rejects nor failed transformations bubble
+	child.FireEvent(ctx, "event", "intercepted")
+	child.FireEvent(ctx, "event", "invalid")
+	assert.Empty(t, received)
+
+	child.FireEvent(ctx, "event", "valid")
+	assert.Equal(t, []EventData{"valid"}, received)
+}
+
+func TestChildEventSwitchLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a and b are each other's parent
+	a := NewEventSwitch(log.TestingLogger()).(*eventSwitch)
+	b := NewChildEventSwitch(log.TestingLogger(), a)
+	a.parent = b
+	for _, evsw := range []EventSwitch{a, b} {
+		require.NoError(t, evsw.Start(ctx))
+		t.Cleanup(evsw.Wait)
+	}
+
+	var received []string
+	require.NoError(t, a.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			received = append(received, "a")
+			return nil
+		}))
+	require.NoError(t, b.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			received = append(received, "b")
+			return nil
+		}))
+
+	b.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"b", "a"}, received)
+
+	received = nil
+	a.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"a", "b"}, received)
+}
+
+func TestChildEventSwitchFireMethods(t *testing.T) {
+	methods := map[string]func(context.Context, EventSwitch, string){
+		"FireEvent": func(ctx context.Context, sw EventSwitch, data string) {
+			require.NoError(t, sw.FireEvent(ctx, "event", data))
+		},
+		"FireEventParallel": func(ctx context.Context, sw EventSwitch, data string) {
+			require.NoError(t, sw.FireEventParallel(ctx, "event", data))
+		},
+		"FireEventAwait": func(ctx context.Context, sw EventSwitch, data string) {
+			require.NoError(t, sw.FireEventAwait(ctx, "event", data, "durable"))
+		},
+		"FireEventFirstSuccess": func(ctx context.Context, sw EventSwitch, data string) {
+			require.NoError(t, sw.FireEventFirstSuccess(ctx, "event", data))
+		},
+		"FireEventCounted": func(ctx context.Context, sw EventSwitch, data string) {
+			delivered, _ := sw.FireEventCounted(ctx, "event", data)
+			require.Equal(t, 1, delivered)
+		},
+	}
+	for name, fire := range methods {
+		fire := fire
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			clock := newManualClock()
+			persister := &memPersister{}
+			parent := NewEventSwitch(log.TestingLogger())
+			require.NoError(t, parent.Start(ctx))
+			t.Cleanup(parent.Wait)
+			child := NewChildEventSwitch(log.TestingLogger(), parent,
+				WithClock(clock), WithPersister(persister), WithLatencyWindow(10))
+			require.NoError(t, child.Start(ctx))
+			t.Cleanup(child.Wait)
+
+			var seqs []uint64
+			require.NoError(t, child.AddDurableListener("durable", "event",
+				func(ctx context.Context, _ EventData) error {
+					seq, _ := SeqFromContext(ctx)
+					seqs = append(seqs, seq)
+					clock.Advance(time.Millisecond)
+					return nil
+				}))
+			var bubbled []EventData
+			require.NoError(t, parent.AddListenerForEvent("global", "event",
+				func(_ context.Context, data EventData) error {
+					bubbled = append(bubbled, data)
+					return nil
+				}))
+
+			fire(ctx, child, "a")
+			// every method persists the fire, records its latency and
+			// bubbles it up to the parent
+			assert.Equal(t, []uint64{1}, seqs)
+			assert.Len(t, persister.persisted, 1)
+			p50, _, _ := child.LatencyPercentiles("event")
+			assert.Equal(t, time.Millisecond, p50)
+			assert.Equal(t, []EventData{"a"}, bubbled)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock is a Clock that only advances when told to.
+type manualClock struct {
+	mtx    sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Unix(0, 0)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	timer := &manualTimer{clock: c, ch: make(chan time.Time, 1)}
+	timer.arm(d)
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward by d and fires the timers that expire.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, timer := range c.timers {
+		if timer.active && !timer.when.After(c.now) {
+			timer.active = false
+			select {
+			case timer.ch <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+// activeTimers returns the number of timers that have not fired or been
+// stopped.
+func (c *manualClock) activeTimers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	n := 0
+	for _, timer := range c.timers {
+		if timer.active {
+			n++
+		}
+	}
+	return n
+}
+
+// waitForTimers blocks until at least n timers are active.
+func (c *manualClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.activeTimers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d active timers", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type manualTimer struct {
+	clock  *manualClock
+	ch     chan time.Time
+	when   time.Time
+	active bool
+}
+
+// arm must be called with the clock lock held.
+func (t *manualTimer) arm(d time.Duration) {
+	t.when = t.clock.now.Add(d)
+	t.active = true
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.ch }
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mtx.Lock()
+	defer t.clock.mtx.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	t.clock.mtx.Lock()
+	defer t.clock.mtx.Unlock()
+
+	wasActive := t.active
+	t.arm(d)
+	return wasActive
+}
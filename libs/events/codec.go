@@ -0,0 +1,92 @@
+package events
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// EventCodec serializes fires, e.g. to capture them to a file and replay
+// them later. Encode returns a self-contained record, which Decode turns
+// back into the fire.
+type EventCodec interface {
+	Encode(ne NamedEvent) ([]byte, error)
+	Decode(b []byte) (NamedEvent, error)
+}
+
+// RedactFunc returns the data of a fire of event as it may be exported, e.g.
+// with its keys and tokens masked. Only the encoded records are redacted:
+// the listeners still receive the data itself, which a RedactFunc must thus
+// not modify, returning a redacted copy instead.
+type RedactFunc func(event string, data EventData) EventData
+
+// redact returns ne with its data redacted by redact, if not nil.
+func redact(redact RedactFunc, ne NamedEvent) NamedEvent {
+	if redact != nil {
+		ne.Data = redact(ne.Event, ne.Data)
+	}
+	return ne
+}
+
+// NDJSONCodec encodes fires as newline-terminated JSON objects, which are
+// human-readable but bulky. The data is decoded the way encoding/json
+// decodes into an interface{}, so that a struct comes back as a
+// map[string]interface{}.
+type NDJSONCodec struct {
+	// Envelope is the envelope fires are encoded in, DefaultEnvelope if
+	// nil. Decode only reads the default Envelope back.
+	Envelope EnvelopeFunc
+	// Redact redacts the data before it is encoded; by default nothing
+	// is redacted.
+	Redact RedactFunc
+}
+
+var _ EventCodec = NDJSONCodec{}
+
+// Encode implements EventCodec.
+func (c NDJSONCodec) Encode(ne NamedEvent) ([]byte, error) {
+	b, err := MarshalEnvelope(c.Envelope, redact(c.Redact, ne))
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Decode implements EventCodec.
+func (NDJSONCodec) Decode(b []byte) (NamedEvent, error) {
+	var env Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return NamedEvent{}, err
+	}
+	return NamedEvent{Event: env.Event, Data: env.Data, Seq: env.Seq, Time: env.TS}, nil
+}
+
+// GobCodec encodes fires with encoding/gob, which is compact and preserves
+// the types of the data. The concrete types of the data must be registered
+// with gob.Register.
+type GobCodec struct {
+	// Redact redacts the data before it is encoded; by default nothing
+	// is redacted.
+	Redact RedactFunc
+}
+
+var _ EventCodec = GobCodec{}
+
+// Encode implements EventCodec.
+func (c GobCodec) Encode(ne NamedEvent) ([]byte, error) {
+	ne = redact(c.Redact, ne)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&ne); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements EventCodec.
+func (GobCodec) Decode(b []byte) (NamedEvent, error) {
+	var ne NamedEvent
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&ne); err != nil {
+		return NamedEvent{}, err
+	}
+	return ne, nil
+}
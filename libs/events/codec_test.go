@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+type codecBlock struct {
+	Height int64
+	Hash   []byte
+}
+
+type codecCredentials struct {
+	User  string
+	Token string
+}
+
+func init() {
+	gob.Register(codecBlock{})
+	gob.Register(codecCredentials{})
+}
+
+func TestNDJSONCodec(t *testing.T) {
+	ne := NamedEvent{
+		Event: "NewBlock",
+		Data:  codecBlock{Height: 7, Hash: []byte{0xab}},
+		Seq:   3,
+		Time:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	b, err := NDJSONCodec{}.Encode(ne)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"event":"NewBlock","data":{"Height":7,"Hash":"qw=="},"seq":3,"ts":"2021-06-01T12:00:00Z"}`+"\n",
+		string(b))
+
+	decoded, err := NDJSONCodec{}.Decode(b)
+	require.NoError(t, err)
+	assert.Equal(t, NamedEvent{
+		Event: "NewBlock",
+		Data:  map[string]interface{}{"Height": float64(7), "Hash": "qw=="},
+		Seq:   3,
+		Time:  ne.Time,
+	}, decoded)
+
+	_, err = NDJSONCodec{}.Decode([]byte("not json"))
+	assert.Error(t, err)
+
+	custom := func(name string, data EventData, seq uint64, _ time.Time) interface{} {
+		return map[string]interface{}{"type": name, "payload": data, "offset": seq}
+	}
+	b, err = NDJSONCodec{Envelope: custom}.Encode(ne)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"offset":3,"payload":{"Height":7,"Hash":"qw=="},"type":"NewBlock"}`+"\n",
+		string(b))
+}
+
+func TestGobCodec(t *testing.T) {
+	ne := NamedEvent{
+		Event: "NewBlock",
+		Data:  codecBlock{Height: 7, Hash: []byte{0xab}},
+		Seq:   3,
+		Time:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	b, err := GobCodec{}.Encode(ne)
+	require.NoError(t, err)
+	decoded, err := GobCodec{}.Decode(b)
+	require.NoError(t, err)
+	assert.Equal(t, ne.Event, decoded.Event)
+	assert.Equal(t, ne.Data, decoded.Data)
+	assert.Equal(t, ne.Seq, decoded.Seq)
+	assert.True(t, ne.Time.Equal(decoded.Time))
+
+	_, err = GobCodec{}.Decode(b[:len(b)/2])
+	assert.Error(t, err)
+}
+
+func TestCodecRedact(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	redact := func(event string, data EventData) EventData {
+		if creds, ok := data.(codecCredentials); ok {
+			creds.Token = "REDACTED"
+			return creds
+		}
+		return data
+	}
+	ndjson := NDJSONCodec{Redact: redact}
+	gobCodec := GobCodec{Redact: redact}
+
+	// a listener capturing the fires it receives
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	var (
+		received     EventData
+		record, gobs []byte
+	)
+	require.NoError(t, evsw.AddListenerForEvent("capture", "login",
+		func(_ context.Context, data EventData) (err error) {
+			received = data
+			ne := NamedEvent{Event: "login", Data: data}
+			if record, err = ndjson.Encode(ne); err != nil {
+				return err
+			}
+			gobs, err = gobCodec.Encode(ne)
+			return err
+		}))
+
+	creds := codecCredentials{User: "alice", Token: "s3cr3t"}
+	require.NoError(t, evsw.FireEvent(ctx, "login", creds))
+
+	// delivery is unaffected, while the records are redacted
+	assert.Equal(t, creds, received)
+	decoded, err := ndjson.Decode(record)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"User": "alice", "Token": "REDACTED"}, decoded.Data)
+	decoded, err = gobCodec.Decode(gobs)
+	require.NoError(t, err)
+	assert.Equal(t, codecCredentials{User: "alice", Token: "REDACTED"}, decoded.Data)
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutDown is returned by CompareAndFire once the switch has been shut
+// down and rejects fires, and by RecvEvent once the switch has stopped.
+var ErrShutDown = errors.New("event switch is shut down")
+
+// lastValues holds the data last fired with CompareAndFire for each event,
+// bounded by WithMaxCachedEvents.
+type lastValues struct {
+	mtx    sync.Mutex
+	values eventLRU
+}
+
+// cacheStats returns the number of events cached and evicted so far.
+func (lv *lastValues) cacheStats() (cached int, evictions uint64) {
+	lv.mtx.Lock()
+	defer lv.mtx.Unlock()
+	return lv.values.len(), lv.values.evictions
+}
+
+func (evsw *eventSwitch) CompareAndFire(
+	ctx context.Context,
+	event string,
+	expected, newData EventData,
+	eq func(a, b EventData) bool,
+) (bool, error) {
+	if eq == nil {
+		return false, ErrNilCallback
+	}
+
+	lv := &evsw.lastValues
+	lv.mtx.Lock()
+	if !eq(lv.values.get(event), expected) {
+		lv.mtx.Unlock()
+		return false, nil
+	}
+	if !evsw.fires.begin() {
+		lv.mtx.Unlock()
+		return false, ErrShutDown
+	}
+	defer evsw.fires.end()
+
+	// A rejected fire must not replace the data later calls expect.
+	prepared, err := evsw.admitFire(ctx, event, newData)
+	if err != nil {
+		lv.mtx.Unlock()
+		evsw.logTransformError(event, err)
+		return false, nil
+	}
+	lv.values.set(event, newData)
+	lv.mtx.Unlock()
+
+	evsw.fireAdmitted(ctx, event, newData, prepared)
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func equalData(a, b EventData) bool { return a == b }
+
+func TestCompareAndFire(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	var received []EventData
+	require.NoError(t, evsw.AddListenerForEvent("listener", "state",
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+
+	fired, err := evsw.CompareAndFire(ctx, "state", nil, "proposed", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+
+	// stale expectation
+	fired, err = evsw.CompareAndFire(ctx, "state", nil, "committed", equalData)
+	require.NoError(t, err)
+	assert.False(t, fired)
+
+	fired, err = evsw.CompareAndFire(ctx, "state", "proposed", "committed", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+	assert.Equal(t, []EventData{"proposed", "committed"}, received)
+
+	// plain fires do not count as the last data
+	evsw.FireEvent(ctx, "state", "other")
+	fired, err = evsw.CompareAndFire(ctx, "state", "committed", "done", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+
+	_, err = evsw.CompareAndFire(ctx, "state", "done", "next", nil)
+	require.ErrorIs(t, err, ErrNilCallback)
+
+	// a rejected fire does not fire nor replace the last data
+	received = nil
+	evsw.DisableEvent("state")
+	fired, err = evsw.CompareAndFire(ctx, "state", "done", "next", equalData)
+	require.NoError(t, err)
+	assert.False(t, fired)
+	evsw.EnableEvent("state")
+	fired, err = evsw.CompareAndFire(ctx, "state", "done", "next", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+	assert.Equal(t, []EventData{"next"}, received)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer shutdownCancel()
+	require.NoError(t, evsw.Shutdown(shutdownCtx))
+	_, err = evsw.CompareAndFire(ctx, "state", "next", "last", equalData)
+	require.ErrorIs(t, err, ErrShutDown)
+}
+
+func TestCompareAndFireConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var fires int32
+	require.NoError(t, evsw.AddListenerForEvent("listener", "state",
+		func(context.Context, EventData) error {
+			atomic.AddInt32(&fires, 1)
+			return nil
+		}))
+
+	// Of concurrent transitions from the same state, exactly one fires.
+	var wg sync.WaitGroup
+	var won int32
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			fired, err := evsw.CompareAndFire(ctx, "state", nil, g, equalData)
+			assert.NoError(t, err)
+			if fired {
+				atomic.AddInt32(&won, 1)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, won)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fires))
+}
+
+func TestCompareAndFireMaxCachedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithMaxCachedEvents(2))
+	require.NoError(t, evsw.Start(ctx))
+
+	for _, event := range []string{"a", "b", "c"} {
+		fired, err := evsw.CompareAndFire(ctx, event, nil, event+"1", equalData)
+		require.NoError(t, err)
+		require.True(t, fired)
+	}
+	stats := evsw.Stats()
+	assert.Equal(t, 2, stats.CachedEvents)
+	assert.Equal(t, uint64(1), stats.CacheEvictions)
+
+	// "a" was evicted, so it compares against nil again
+	fired, err := evsw.CompareAndFire(ctx, "a", nil, "a2", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+	fired, err = evsw.CompareAndFire(ctx, "c", "c1", "c2", equalData)
+	require.NoError(t, err)
+	assert.True(t, fired)
+}
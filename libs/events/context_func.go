@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ContextFunc derives the context passed to the callbacks of a listener
+// from the context of the fire, see SetContextFunc.
+type ContextFunc func(ctx context.Context) context.Context
+
+// contextFuncs holds the context functions of the listeners. Like
+// disabledEvents, it is only consulted when it is not empty.
+type contextFuncs struct {
+	count int32 // atomic
+
+	mtx   sync.RWMutex
+	funcs map[string]ContextFunc
+}
+
+func (evsw *eventSwitch) SetContextFunc(listenerID string, fn ContextFunc) {
+	if fn == nil {
+		evsw.ctxFuncs.remove(listenerID)
+		return
+	}
+
+	c := &evsw.ctxFuncs
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.funcs == nil {
+		c.funcs = make(map[string]ContextFunc)
+	}
+	if _, ok := c.funcs[listenerID]; !ok {
+		atomic.AddInt32(&c.count, 1)
+	}
+	c.funcs[listenerID] = fn
+}
+
+func (c *contextFuncs) remove(listenerID string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.funcs[listenerID]; ok {
+		delete(c.funcs, listenerID)
+		atomic.AddInt32(&c.count, -1)
+	}
+}
+
+func (c *contextFuncs) get(listenerID string) ContextFunc {
+	if atomic.LoadInt32(&c.count) == 0 {
+		return nil
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.funcs[listenerID]
+}
+
+// withContextFunc returns lc with a callback passing the context through
+// fn first, so that fn runs wherever the callback does, e.g. under panic
+// recovery.
+func withContextFunc(lc listenerCallback, fn ContextFunc) listenerCallback {
+	cb := lc.cb
+	lc.cb = func(ctx context.Context, data EventData) error {
+		return cb(fn(ctx), data)
+	}
+	return lc
+}
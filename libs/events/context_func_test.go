@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+type scopeKey struct{}
+
+func TestSetContextFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	scopes := map[string]interface{}{}
+	for _, listenerID := range []string{"enriched", "plain"} {
+		listenerID := listenerID
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "event",
+			func(ctx context.Context, _ EventData) error {
+				scopes[listenerID] = ctx.Value(scopeKey{})
+				return nil
+			}))
+	}
+	evsw.SetContextFunc("enriched", func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, scopeKey{}, "scoped")
+	})
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, map[string]interface{}{"enriched": "scoped", "plain": nil}, scopes)
+
+	evsw.SetContextFunc("enriched", nil)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Nil(t, scopes["enriched"])
+}
+
+func TestContextFuncPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithPanicEvents())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	called := false
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			called = true
+			return nil
+		}))
+	evsw.SetContextFunc("listener", func(context.Context) context.Context { panic("boom") })
+
+	delivered, _ := evsw.FireEventCounted(ctx, "event", nil)
+	assert.Equal(t, 1, delivered)
+	assert.False(t, called)
+	assert.Equal(t, uint64(1), evsw.Report().CallbackErrors)
+
+	// removing the listener removes its function
+	evsw.RemoveListener("listener")
+	assert.Zero(t, evsw.(*eventSwitch).ctxFuncs.count)
+}
@@ -0,0 +1,23 @@
+package events
+
+import "context"
+
+// correlationIDKey is the context key of the ID attached by FireEventWithID.
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation ID
+// corrID. Fires made with the returned context, and the fires made by their
+// callbacks with the context they receive, carry the ID along.
+func ContextWithCorrelationID(ctx context.Context, corrID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, corrID)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	corrID, ok := ctx.Value(correlationIDKey{}).(string)
+	return corrID, ok
+}
+
+func (evsw *eventSwitch) FireEventWithID(ctx context.Context, event string, data EventData, corrID string) error {
+	return evsw.FireEvent(ContextWithCorrelationID(ctx, corrID), event, data)
+}
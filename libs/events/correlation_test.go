@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireEventWithID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	_, ok := CorrelationIDFromContext(ctx)
+	assert.False(t, ok)
+
+	var got []string
+	record := func(ctx context.Context, data EventData) error {
+		corrID, ok := CorrelationIDFromContext(ctx)
+		assert.True(t, ok)
+		got = append(got, corrID)
+		return nil
+	}
+
+	// the ID reaches the listeners of the events fired downstream
+	require.NoError(t, evsw.AddListenerForEvent("upstream", "request",
+		func(ctx context.Context, data EventData) error {
+			_ = record(ctx, data)
+			evsw.FireEvent(ctx, "downstream", data)
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("downstream", "downstream", record))
+
+	evsw.FireEventWithID(ctx, "request", nil, "req-1")
+	assert.Equal(t, []string{"req-1", "req-1"}, got)
+}
@@ -0,0 +1,22 @@
+package events
+
+// DeadLetter is the event data of the dead-letter event, see
+// SetDeadLetterEvent.
+type DeadLetter struct {
+	// OriginalEvent is the event that was fired without listeners.
+	OriginalEvent string
+	// Data is the data it was fired with.
+	Data EventData
+}
+
+func (evsw *eventSwitch) SetDeadLetterEvent(event string) {
+	evsw.mtx.Lock()
+	evsw.deadLetter = event
+	evsw.mtx.Unlock()
+}
+
+func (evsw *eventSwitch) deadLetterEvent() string {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+	return evsw.deadLetter
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestDeadLetterEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	// without listeners for the dead-letter event, nothing happens
+	evsw.SetDeadLetterEvent("unhandled")
+	evsw.FireEvent(ctx, "event", 1)
+
+	var unhandled []EventData
+	require.NoError(t, evsw.AddListenerForEvent("dlq", "unhandled",
+		func(_ context.Context, data EventData) error {
+			unhandled = append(unhandled, data)
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("listener", "handled",
+		func(context.Context, EventData) error { return nil }))
+
+	evsw.FireEvent(ctx, "event", 2)
+	evsw.FireEvent(ctx, "handled", 3)
+	require.NoError(t, evsw.FireEventParallel(ctx, "other", 4))
+	assert.Equal(t, []EventData{
+		DeadLetter{OriginalEvent: "event", Data: 2},
+		DeadLetter{OriginalEvent: "other", Data: 4},
+	}, unhandled)
+
+	// the dead-letter event is delivered as is
+	evsw.FireEvent(ctx, "unhandled", 5)
+	assert.Equal(t, 5, unhandled[2])
+
+	evsw.SetDeadLetterEvent("")
+	evsw.FireEvent(ctx, "event", 6)
+	assert.Len(t, unhandled, 3)
+}
+
+func TestDeadLetterEventWithoutListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	evsw.SetDeadLetterEvent("unhandled")
+	evsw.FireEvent(ctx, "unhandled", nil)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, uint64(2), evsw.Report().EventsFired)
+}
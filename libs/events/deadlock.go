@@ -0,0 +1,94 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+// ErrWouldDeadlock is returned by Shutdown when the deadlock detector (see
+// WithDeadlockDetection) finds it called from a callback, since the fire
+// running that callback could never complete.
+var ErrWouldDeadlock = errors.New("called from an event callback, waiting would deadlock")
+
+// deadlockDetector tracks which goroutines are running callbacks, so the
+// methods waiting for callbacks to return can tell when they would be
+// waiting for their own caller.
+type deadlockDetector struct {
+	mtx sync.Mutex
+	// running maps a goroutine to the listeners whose callbacks it is
+	// running, innermost last; callbacks nest when they fire events.
+	running map[uint64][]string
+}
+
+func newDeadlockDetector() *deadlockDetector {
+	return &deadlockDetector{running: make(map[uint64][]string)}
+}
+
+// enter records that the current goroutine runs a callback of the listener
+// and returns the goroutine to pass to exit.
+func (d *deadlockDetector) enter(listenerID string) uint64 {
+	gid := goroutineID()
+	d.mtx.Lock()
+	d.running[gid] = append(d.running[gid], listenerID)
+	d.mtx.Unlock()
+	return gid
+}
+
+func (d *deadlockDetector) exit(gid uint64) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if n := len(d.running[gid]); n > 1 {
+		d.running[gid] = d.running[gid][:n-1]
+	} else {
+		delete(d.running, gid)
+	}
+}
+
+// inCallback reports whether the current goroutine runs a callback of the
+// listener, or any callback if listenerID is empty.
+func (d *deadlockDetector) inCallback(listenerID string) bool {
+	gid := goroutineID()
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	listeners := d.running[gid]
+	if listenerID == "" {
+		return len(listeners) > 0
+	}
+	for _, id := range listeners {
+		if id == listenerID {
+			return true
+		}
+	}
+	return false
+}
+
+// wouldDeadlock reports whether method, about to wait for the callbacks of
+// the listener (or for every callback if listenerID is empty), is called
+// from one of them, and logs a stack trace if so.
+func (evsw *eventSwitch) wouldDeadlock(method, listenerID string) bool {
+	if evsw.deadlocks == nil || !evsw.deadlocks.inCallback(listenerID) {
+		return false
+	}
+	evsw.logger.Error("event callback calls a method that would deadlock",
+		"method", method, "listener", listenerID, "stack", string(debug.Stack()))
+	return true
+}
+
+// goroutineID returns the ID of the current goroutine, as printed in stack
+// traces. It is slow, which is why only the deadlock detector uses it.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
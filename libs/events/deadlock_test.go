@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestWithDeadlockDetection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(logger, WithDeadlockDetection())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("self", "remove",
+		func(context.Context, EventData) error {
+			evsw.RemoveListenerWait("self")
+			return nil
+		}))
+	shutdownErr := make(chan error, 1)
+	require.NoError(t, evsw.AddListenerForEvent("stopper", "shutdown",
+		func(ctx context.Context, _ EventData) error {
+			shutdownErr <- evsw.Shutdown(ctx)
+			return nil
+		}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evsw.FireEvent(ctx, "remove", nil)
+		evsw.FireEvent(ctx, "shutdown", nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback deadlocked")
+	}
+	require.ErrorIs(t, <-shutdownErr, ErrWouldDeadlock)
+	assert.Empty(t, evsw.Listeners("remove"))
+
+	var logged []string
+	for _, msg := range logger.messages() {
+		if strings.Contains(msg, "would deadlock") {
+			logged = append(logged, msg)
+		}
+	}
+	require.Len(t, logged, 2)
+	assert.Contains(t, logged[0], "method RemoveListenerWait listener self")
+	assert.Contains(t, logged[0], "TestWithDeadlockDetection")
+	assert.Contains(t, logged[1], "method Shutdown")
+}
+
+func TestDeadlockDetectionOtherListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithDeadlockDetection())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	// Waiting for another listener is fine and still waits.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, evsw.AddListenerForEvent("slow", "slow",
+		func(context.Context, EventData) error {
+			close(started)
+			<-release
+			return nil
+		}))
+	removed := make(chan struct{})
+	require.NoError(t, evsw.AddListenerForEvent("remover", "remove",
+		func(context.Context, EventData) error {
+			evsw.RemoveListenerWait("slow")
+			close(removed)
+			return nil
+		}))
+
+	go evsw.FireEvent(ctx, "slow", nil)
+	<-started
+	go evsw.FireEvent(ctx, "remove", nil)
+	select {
+	case <-removed:
+		t.Fatal("RemoveListenerWait did not wait for the other listener")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RemoveListenerWait did not return")
+	}
+
+	d := evsw.(*eventSwitch).deadlocks
+	require.Eventually(t, func() bool {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+		return len(d.running) == 0
+	}, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// DeltaCallback is the callback of a delta listener, see AddDeltaListener.
+// prev is the data of the previous fire delivered to the listener, or nil
+// for the first one, and cur the data of the current fire.
+type DeltaCallback func(ctx context.Context, prev, cur EventData) error
+
+// deltaListener remembers the last data delivered to a delta listener.
+type deltaListener struct {
+	cb DeltaCallback
+
+	mtx  sync.Mutex
+	last EventData
+}
+
+func (dl *deltaListener) fire(ctx context.Context, data EventData) error {
+	dl.mtx.Lock()
+	prev := dl.last
+	dl.last = data
+	dl.mtx.Unlock()
+
+	return dl.cb(ctx, prev, data)
+}
+
+// AddDeltaListener subscribes cb to event. Only delta listeners retain the
+// data of past fires, and each of them only the most recent one, so events
+// without delta listeners cost nothing extra. Resubscribing the listener,
+// or replacing its callback, starts over with a nil prev.
+func (evsw *eventSwitch) AddDeltaListener(listenerID, event string, cb DeltaCallback) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+	dl := &deltaListener{cb: cb}
+	return evsw.addListener(listenerID, event, dl.fire, subState{})
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddDeltaListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.ErrorIs(t, evsw.AddDeltaListener("listener", "event", nil), ErrNilCallback)
+
+	type delta struct{ prev, cur EventData }
+	var deltas []delta
+	require.NoError(t, evsw.AddDeltaListener("listener", "event",
+		func(_ context.Context, prev, cur EventData) error {
+			deltas = append(deltas, delta{prev, cur})
+			return nil
+		}))
+
+	evsw.FireEvent(ctx, "event", 1)
+	evsw.FireEvent(ctx, "event", 2)
+	evsw.FireEvent(ctx, "event", 3)
+	assert.Equal(t, []delta{{nil, 1}, {1, 2}, {2, 3}}, deltas)
+
+	// a listener added later starts from its own first fire
+	deltas = nil
+	var late []delta
+	require.NoError(t, evsw.AddDeltaListener("late", "event",
+		func(_ context.Context, prev, cur EventData) error {
+			late = append(late, delta{prev, cur})
+			return nil
+		}))
+	evsw.FireEvent(ctx, "event", 4)
+	assert.Equal(t, []delta{{3, 4}}, deltas)
+	assert.Equal(t, []delta{{nil, 4}}, late)
+}
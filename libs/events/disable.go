@@ -0,0 +1,52 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// disabledEvents is the set of disabled events. Since events are rarely
+// disabled, the set is only consulted when it is not empty.
+type disabledEvents struct {
+	count int32 // atomic
+
+	mtx    sync.RWMutex
+	events map[string]struct{}
+}
+
+func (evsw *eventSwitch) DisableEvent(event string) {
+	d := &evsw.disabled
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.events == nil {
+		d.events = make(map[string]struct{})
+	}
+	if _, ok := d.events[event]; !ok {
+		d.events[event] = struct{}{}
+		atomic.AddInt32(&d.count, 1)
+	}
+}
+
+func (evsw *eventSwitch) EnableEvent(event string) {
+	d := &evsw.disabled
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if _, ok := d.events[event]; ok {
+		delete(d.events, event)
+		atomic.AddInt32(&d.count, -1)
+	}
+}
+
+func (evsw *eventSwitch) isDisabled(event string) bool {
+	d := &evsw.disabled
+	if atomic.LoadInt32(&d.count) == 0 {
+		return false
+	}
+
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	_, ok := d.events[event]
+	return ok
+}
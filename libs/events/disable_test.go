@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestDisableEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := map[string]int{}
+	for _, event := range []string{"noisy", "quiet"} {
+		event := event
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(context.Context, EventData) error {
+				received[event]++
+				return nil
+			}))
+	}
+
+	evsw.DisableEvent("noisy")
+	evsw.DisableEvent("noisy")
+	evsw.FireEvent(ctx, "noisy", nil)
+	evsw.FireEvent(ctx, "quiet", nil)
+	assert.Equal(t, map[string]int{"quiet": 1}, received)
+	assert.Equal(t, uint64(1), evsw.Report().DisabledFires)
+
+	evsw.EnableEvent("noisy")
+	evsw.EnableEvent("noisy")
+	evsw.FireEvent(ctx, "noisy", nil)
+	assert.Equal(t, map[string]int{"noisy": 1, "quiet": 1}, received)
+	assert.Zero(t, evsw.(*eventSwitch).disabled.count)
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (evsw *eventSwitch) ExportDOT(w io.Writer) error {
+	// The topology is collected first so that w is not written to under
+	// the read lock held by RangeEvents.
+	type subscription struct {
+		event       string
+		listenerIDs []string
+	}
+	var subs []subscription
+	evsw.RangeEvents(func(event string, listenerIDs []string) bool {
+		subs = append(subs, subscription{event, listenerIDs})
+		return true
+	})
+
+	listeners := make(map[string]bool)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph events {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	for _, sub := range subs {
+		fmt.Fprintf(bw, "\t%s [shape=box, label=%s];\n", dotID("event", sub.event), dotQuote(sub.event))
+		for _, listenerID := range sub.listenerIDs {
+			if !listeners[listenerID] {
+				listeners[listenerID] = true
+				fmt.Fprintf(bw, "\t%s [shape=ellipse, label=%s];\n",
+					dotID("listener", listenerID), dotQuote(listenerID))
+			}
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotID("event", sub.event), dotID("listener", listenerID))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotID returns the quoted ID of a node, namespaced by kind so that an event
+// and a listener of the same name are distinct nodes.
+func dotID(kind, name string) string {
+	return dotQuote(kind + ":" + name)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestExportDOT(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	cb := func(context.Context, EventData) error { return nil }
+	require.NoError(t, evsw.AddListenerForEvent("consensus", "Vote", cb))
+	require.NoError(t, evsw.AddListenerForEvent("consensus", "NewRoundStep", cb))
+	require.NoError(t, evsw.AddListenerForEvent(`rpc "ws"`, "Vote", cb))
+
+	var buf bytes.Buffer
+	require.NoError(t, evsw.ExportDOT(&buf))
+	assert.Equal(t, `digraph events {
+	rankdir=LR;
+	"event:NewRoundStep" [shape=box, label="NewRoundStep"];
+	"listener:consensus" [shape=ellipse, label="consensus"];
+	"event:NewRoundStep" -> "listener:consensus";
+	"event:Vote" [shape=box, label="Vote"];
+	"event:Vote" -> "listener:consensus";
+	"listener:rpc \"ws\"" [shape=ellipse, label="rpc \"ws\""];
+	"event:Vote" -> "listener:rpc \"ws\"";
+}
+`, buf.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestExportDOTWriteError(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.Error(t, evsw.ExportDOT(failingWriter{}))
+}
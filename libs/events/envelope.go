@@ -0,0 +1,43 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EnvelopeFunc returns the object a fire is marshaled as when exported as
+// JSON, letting the export match the schema of an existing log pipeline.
+// name, seq and ts are the event, sequence number and time of the fire.
+// MarshalEnvelope passes the data already marshaled, as a json.RawMessage,
+// to be embedded in the object as is.
+type EnvelopeFunc func(name string, data EventData, seq uint64, ts time.Time) interface{}
+
+// Envelope is the JSON object fires are exported as by default.
+type Envelope struct {
+	Event string    `json:"event"`
+	Data  EventData `json:"data"`
+	Seq   uint64    `json:"seq"`
+	TS    time.Time `json:"ts"`
+}
+
+// DefaultEnvelope is the default EnvelopeFunc, which wraps fires in an
+// Envelope.
+func DefaultEnvelope(name string, data EventData, seq uint64, ts time.Time) interface{} {
+	return Envelope{Event: name, Data: data, Seq: seq, TS: ts}
+}
+
+// MarshalEnvelope marshals ne as JSON in the envelope returned by envelope,
+// or by DefaultEnvelope if envelope is nil. The data is marshaled with
+// MarshalEventData, so that an EventMarshaler controls its own
+// representation, which must then be valid JSON, and protobuf messages are
+// embedded as proto-JSON.
+func MarshalEnvelope(envelope EnvelopeFunc, ne NamedEvent) ([]byte, error) {
+	if envelope == nil {
+		envelope = DefaultEnvelope
+	}
+	data, err := MarshalEventData(ne.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope(ne.Event, json.RawMessage(data), ne.Seq, ne.Time))
+}
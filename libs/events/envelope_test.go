@@ -0,0 +1,50 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalEnvelope(t *testing.T) {
+	ne := NamedEvent{
+		Event: "NewBlock",
+		Data:  map[string]int{"height": 7},
+		Seq:   3,
+		Time:  time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	b, err := MarshalEnvelope(nil, ne)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"event":"NewBlock","data":{"height":7},"seq":3,"ts":"2021-06-01T12:00:00Z"}`,
+		string(b))
+
+	custom := func(name string, data EventData, seq uint64, ts time.Time) interface{} {
+		return map[string]interface{}{
+			"type":      name,
+			"payload":   data,
+			"offset":    seq,
+			"timestamp": ts.Unix(),
+		}
+	}
+	b, err = MarshalEnvelope(custom, ne)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"type":"NewBlock","payload":{"height":7},"offset":3,"timestamp":1622548800}`,
+		string(b))
+
+	// the data is embedded the way its EventMarshaler marshals it
+	ne.Data = versionedHeight(7)
+	b, err = MarshalEnvelope(nil, ne)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"event":"NewBlock","data":{"v":2,"height":7},"seq":3,"ts":"2021-06-01T12:00:00Z"}`,
+		string(b))
+
+	ne.Data = versionedHeight(-1)
+	_, err = MarshalEnvelope(nil, ne)
+	require.EqualError(t, err, "negative height")
+}
@@ -0,0 +1,35 @@
+package events
+
+import "fmt"
+
+// ErrorHandler handles the errors returned by callbacks, see
+// SetErrorHandler.
+type ErrorHandler func(listenerID, event string, err error)
+
+func (evsw *eventSwitch) SetErrorHandler(handler ErrorHandler) {
+	evsw.mtx.Lock()
+	evsw.errorHandler = handler
+	evsw.mtx.Unlock()
+}
+
+// handleError hands the error returned by a callback of the listener to the
+// error handler, or logs it if there is none. A panicking handler is logged
+// rather than allowed to crash the fire.
+func (evsw *eventSwitch) handleError(listenerID, event string, err error) {
+	evsw.mtx.RLock()
+	handler := evsw.errorHandler
+	evsw.mtx.RUnlock()
+
+	if handler == nil {
+		evsw.logger.Error("event callback failed", "listener", listenerID, "event", event, "err", err)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			evsw.logger.Error("event error handler panicked",
+				"listener", listenerID, "event", event, "err", err, "panic", fmt.Sprint(r))
+		}
+	}()
+	handler(listenerID, event, err)
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+type handledError struct {
+	listenerID, event string
+	err               error
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(logger, WithClock(newManualClock()))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errFailed := errors.New("failed")
+	require.NoError(t, evsw.AddListenerForEvent("failing", "event",
+		func(context.Context, EventData) error { return errFailed }))
+	require.NoError(t, evsw.AddListenerForEvent("ok", "event",
+		func(context.Context, EventData) error { return nil }))
+
+	// default: logged
+	evsw.FireEvent(ctx, "event", nil)
+	var logged []string
+	for _, msg := range logger.messages() {
+		if strings.Contains(msg, "event callback failed") {
+			logged = append(logged, msg)
+		}
+	}
+	require.Len(t, logged, 1)
+	assert.Contains(t, logged[0], "listener failing event event err failed")
+
+	var mtx sync.Mutex
+	var handled []handledError
+	evsw.SetErrorHandler(func(listenerID, event string, err error) {
+		// the switch is not locked while the handler runs
+		evsw.SetDeadLetterEvent("")
+		mtx.Lock()
+		handled = append(handled, handledError{listenerID, event, err})
+		mtx.Unlock()
+	})
+
+	evsw.FireEvent(ctx, "event", nil)
+	require.Error(t, evsw.FireEventParallel(ctx, "event", nil))
+	mtx.Lock()
+	assert.Equal(t, []handledError{
+		{"failing", "event", errFailed},
+		{"failing", "event", errFailed},
+	}, handled)
+	mtx.Unlock()
+
+	// a panicking handler is logged and does not crash the fire
+	evsw.SetErrorHandler(func(string, string, error) { panic("boom") })
+	evsw.FireEvent(ctx, "event", nil)
+	found := false
+	for _, msg := range logger.messages() {
+		found = found || strings.Contains(msg, "event error handler panicked")
+	}
+	assert.True(t, found)
+}
+
+func TestErrorHandlerBatching(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	handled := make(chan handledError, 1)
+	evsw.SetErrorHandler(func(listenerID, event string, err error) {
+		handled <- handledError{listenerID, event, err}
+	})
+
+	errFailed := errors.New("failed")
+	require.NoError(t, evsw.AddBatchingListener("batcher", "event", time.Hour, 1,
+		func(context.Context, []EventData) error { return errFailed }))
+	evsw.FireEvent(ctx, "event", nil)
+
+	select {
+	case h := <-handled:
+		assert.Equal(t, handledError{"batcher", "event", errFailed}, h)
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch error was not handled")
+	}
+}
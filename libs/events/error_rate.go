@@ -0,0 +1,80 @@
+package events
+
+import "sync"
+
+// errorRates tracks the outcome of the most recent invocations of each
+// listener.
+type errorRates struct {
+	window int
+
+	mtx   sync.Mutex
+	rings map[string]*outcomeRing
+}
+
+func newErrorRates(window int) *errorRates {
+	return &errorRates{
+		window: window,
+		rings:  make(map[string]*outcomeRing),
+	}
+}
+
+func (er *errorRates) record(listenerID string, failed bool) {
+	er.mtx.Lock()
+	defer er.mtx.Unlock()
+
+	ring := er.rings[listenerID]
+	if ring == nil {
+		ring = &outcomeRing{failed: make([]bool, er.window)}
+		er.rings[listenerID] = ring
+	}
+	ring.add(failed)
+}
+
+func (er *errorRates) rate(listenerID string) float64 {
+	er.mtx.Lock()
+	defer er.mtx.Unlock()
+
+	ring := er.rings[listenerID]
+	if ring == nil || ring.size == 0 {
+		return 0
+	}
+	return float64(ring.failures) / float64(ring.size)
+}
+
+func (er *errorRates) remove(listenerID string) {
+	er.mtx.Lock()
+	delete(er.rings, listenerID)
+	er.mtx.Unlock()
+}
+
+// outcomeRing is a fixed-size ring of invocation outcomes.
+type outcomeRing struct {
+	failed   []bool
+	next     int
+	size     int
+	failures int
+}
+
+func (r *outcomeRing) add(failed bool) {
+	if r.size == len(r.failed) {
+		// overwrite the oldest outcome
+		if r.failed[r.next] {
+			r.failures--
+		}
+	} else {
+		r.size++
+	}
+
+	r.failed[r.next] = failed
+	if failed {
+		r.failures++
+	}
+	r.next = (r.next + 1) % len(r.failed)
+}
+
+func (evsw *eventSwitch) ListenerErrorRate(listenerID string) float64 {
+	if evsw.errorRates == nil {
+		return 0
+	}
+	return evsw.errorRates.rate(listenerID)
+}
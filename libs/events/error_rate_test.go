@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestListenerErrorRate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithErrorRateWindow(4))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("flaky", "event",
+		func(_ context.Context, data EventData) error {
+			if data.(bool) {
+				return errors.New("failed")
+			}
+			return nil
+		}))
+	assert.Zero(t, evsw.ListenerErrorRate("flaky"))
+
+	evsw.FireEvent(ctx, "event", true)
+	evsw.FireEvent(ctx, "event", false)
+	assert.Equal(t, 0.5, evsw.ListenerErrorRate("flaky"))
+
+	evsw.FireEvent(ctx, "event", false)
+	evsw.FireEvent(ctx, "event", false)
+	assert.Equal(t, 0.25, evsw.ListenerErrorRate("flaky"))
+
+	// the oldest failure falls out of the window
+	evsw.FireEvent(ctx, "event", false)
+	assert.Zero(t, evsw.ListenerErrorRate("flaky"))
+
+	evsw.FireEvent(ctx, "event", true)
+	evsw.RemoveListener("flaky")
+	assert.Zero(t, evsw.ListenerErrorRate("flaky"))
+}
+
+func TestListenerErrorRateDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Zero(t, evsw.ListenerErrorRate("listener"))
+}
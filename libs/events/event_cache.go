@@ -29,11 +29,17 @@ func (evc *EventCache) FireEvent(event string, data EventData) {
 }
 
 // Fire events by running evsw.FireEvent on all cached events. Blocks.
-// Clears cached events
-func (evc *EventCache) Flush(ctx context.Context) {
-	for _, ei := range evc.events {
-		evc.evsw.FireEvent(ctx, ei.event, ei.data)
-	}
+// Clears cached events, including those left unfired once ctx is done, in
+// which case it returns ctx.Err().
+func (evc *EventCache) Flush(ctx context.Context) error {
 	// Clear the buffer, since we only add to it with append it's safe to just set it to nil and maybe safe an allocation
+	events := evc.events
 	evc.events = nil
+
+	for _, ei := range events {
+		if err := evc.evsw.FireEvent(ctx, ei.event, ei.data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -25,9 +25,9 @@ func TestEventCache_Flush(t *testing.T) {
 	require.NoError(t, err)
 
 	evc := NewEventCache(evsw)
-	evc.Flush(ctx)
+	require.NoError(t, evc.Flush(ctx))
 	// Check after reset
-	evc.Flush(ctx)
+	require.NoError(t, evc.Flush(ctx))
 	fail := true
 	pass := false
 	err = evsw.AddListenerForEvent("somethingness", "something", func(_ context.Context, data EventData) error {
@@ -43,6 +43,6 @@ func TestEventCache_Flush(t *testing.T) {
 	evc.FireEvent("something", struct{ int }{2})
 	evc.FireEvent("something", struct{ int }{3})
 	fail = false
-	evc.Flush(ctx)
+	require.NoError(t, evc.Flush(ctx))
 	assert.True(t, pass)
 }
@@ -3,8 +3,13 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
@@ -20,10 +25,39 @@ func (e ErrListenerWasRemoved) Error() string {
 	return fmt.Sprintf("listener #%s was removed", e.listenerID)
 }
 
+// ErrNilCallback is returned when a listener is registered with a nil
+// callback.
+var ErrNilCallback = errors.New("callback must not be nil")
+
+// ErrStopPropagation can be returned by a callback, possibly wrapped, to stop
+// the fire from reaching the listeners that come after it. It is only
+// honored by the fires that invoke the listeners one after the other, and
+// is not considered a callback failure.
+var ErrStopPropagation = errors.New("stop event propagation")
+
+// ErrListenerNotSubscribed is returned by ReplaceListenerCallback if the
+// listener is not subscribed to the event.
+type ErrListenerNotSubscribed struct {
+	listenerID string
+	event      string
+}
+
+// Error implements the error interface.
+func (e ErrListenerNotSubscribed) Error() string {
+	return fmt.Sprintf("listener #%s is not subscribed to %s", e.listenerID, e.event)
+}
+
 // EventData is a generic event data can be typed and registered with
 // tendermint/go-amino via concrete implementation of this interface.
 type EventData interface{}
 
+// Clonable is implemented by event data that can be deep-copied. A switch
+// created with WithClonePerListener hands every listener its own clone of
+// such data, so listeners mutating it cannot corrupt each other.
+type Clonable interface {
+	Clone() EventData
+}
+
 // Eventable is the interface reactors and other modules must export to become
 // eventable.
 type Eventable interface {
@@ -32,9 +66,11 @@ type Eventable interface {
 
 // Fireable is the interface that wraps the FireEvent method.
 //
-// FireEvent fires an event with the given name and data.
+// FireEvent fires an event with the given name and data. It returns
+// ctx.Err() if ctx is done once the fire returns, in which case some of the
+// listeners may have missed the event.
 type Fireable interface {
-	FireEvent(ctx context.Context, eventValue string, data EventData)
+	FireEvent(ctx context.Context, eventValue string, data EventData) error
 }
 
 // EventSwitch is the interface for synchronous pubsub, where listeners
@@ -44,40 +80,618 @@ type Fireable interface {
 // Listeners are added by calling AddListenerForEvent function.
 // They can be removed by calling either RemoveListenerForEvent or
 // RemoveListener (for all events).
+//
+// FireEvent invokes the callbacks one after the other, in the order the
+// listeners subscribed to the event, on the calling goroutine: it returns
+// only once every callback has returned, so a slow or blocking callback
+// blocks the caller. It stops once ctx is done, returning ctx.Err(), or a
+// callback returns ErrStopPropagation; the listeners not invoked yet then
+// miss the event.
+// FireEventNonBlocking hands the fire to a fixed pool of workers instead.
+//
+// Every callback receives ctx, or a context derived from it that carries
+// its values, deadline and cancellation, so that a fire made on behalf of a
+// request is abandoned with it: a callback must return promptly once its
+// context is done. Work that must outlive the caller is fired with
+// FireEventDetached, which delivers with the context of the switch instead.
+//
+// Listeners are snapshotted before their callbacks are invoked and no lock is
+// held while a callback runs, so callbacks may add, remove or replace
+// listeners (including themselves). A change made during a fire takes effect
+// for the next fire.
+//
+// A switch can be started only once: every Start after the first returns
+// service.ErrAlreadyStarted, even once the switch has stopped. This holds for
+// concurrent calls too, of which exactly one starts the switch. Wait may be
+// called any number of times.
 type EventSwitch interface {
 	service.Service
 	Fireable
 	Stop() error
 
 	AddListenerForEvent(listenerID, eventValue string, cb EventCallback) error
+	// AddListenerForEventBlocking registers the listener like
+	// AddListenerForEvent, then waits until the switch is started. If ctx is
+	// done first it returns ctx.Err(); the listener stays registered.
+	AddListenerForEventBlocking(ctx context.Context, listenerID, eventValue string, cb EventCallback) error
+
+	// AddListenerWithStopPriority registers the listener like
+	// AddListenerForEvent, along with a hook tearing it down when the switch
+	// stops. The hooks of all listeners run one after the other, after
+	// SwitchStopping is fired and before the switch context is cancelled,
+	// in order of increasing priority: a hook depending on the teardown of
+	// others, e.g. one flushing the metrics they update, must have a higher
+	// priority than theirs. Hooks of equal priority run in reverse order of
+	// registration. A listener has at most one hook, the latest registered,
+	// which is dropped when the listener is removed.
+	AddListenerWithStopPriority(listenerID, event string, cb EventCallback, priority int, onStop StopHook) error
 	RemoveListenerForEvent(event string, listenerID string)
 	RemoveListener(listenerID string)
+
+	// RemoveAllListeners removes every listener from every event. Fires that
+	// already snapshotted the listeners of an event complete normally; fires
+	// that start afterwards see no listeners.
+	RemoveAllListeners()
+
+	// RemoveListenerWait removes the listener like RemoveListener and then
+	// waits for the invocations of its callbacks that are in progress to
+	// return. Unlike with RemoveListener, fires that snapshotted the
+	// listener before its removal skip it, so once RemoveListenerWait
+	// returns the callbacks are not invoked again and the resources they use
+	// may be released. It must not be called from one of the callbacks.
+	RemoveListenerWait(listenerID string)
+
+	// AddListenerForEventName and FireEventName are the EventName
+	// counterparts of AddListenerForEvent and FireEvent, and should be
+	// preferred for well-known events.
+	AddListenerForEventName(listenerID string, event EventName, cb EventCallback) error
+	FireEventName(ctx context.Context, event EventName, data EventData) error
+
+	// RemoveListenersForEventPrefix removes all listeners of every event whose
+	// name starts with prefix and returns the number of (listener, event)
+	// pairs removed. Fires that already snapshotted the listeners of such an
+	// event complete; later fires see no listeners.
+	RemoveListenersForEventPrefix(prefix string) int
+
+	// AddWeakListener subscribes cb to event for as long as owner is
+	// reachable and returns the generated listener ID.
+	AddWeakListener(owner interface{}, event string, cb EventCallback) (string, error)
+
+	// AddFailoverListener adds cb as a member of the failover group groupID
+	// for event. Each fire is delivered to the members in registration order
+	// until one succeeds, i.e. returns nil or ErrStopPropagation; a member
+	// fails over to the next by returning another error or, on a switch
+	// created with WithFailoverTimeout, by not returning in time. The group
+	// is a single listener with ID groupID.
+	AddFailoverListener(groupID, event string, cb EventCallback) error
+
+	// AddBatchingListener subscribes cb to event and delivers the fired data
+	// to it in batches, in the order it was fired. A batch is delivered once
+	// window has elapsed since its first element was fired or once it holds
+	// maxBatch elements, whichever comes first. Batches are delivered with
+	// the context of the switch, whatever the contexts of their fires.
+	AddBatchingListener(listenerID, event string, window time.Duration, maxBatch int,
+		cb BatchCallback) error
+
+	// AddDeltaListener subscribes cb to event, passing it the data of each
+	// fire along with the data of the previous fire it received.
+	AddDeltaListener(listenerID, event string, cb DeltaCallback) error
+
+	// AddDurableListener subscribes cb to event like AddListenerForEvent,
+	// and makes the fires of event, whatever the fire method, persisted by
+	// the Persister of a switch created with WithPersister before they are
+	// delivered to any of its listeners. The persisted fires are numbered
+	// in the order they were persisted, continuing after the last one
+	// persisted before the switch started, and the callbacks read the
+	// number with SeqFromContext: a listener recording the last number it
+	// processed along with its own state can thus tell, after a restart,
+	// which fires it has already processed. If the Persister is a Replayer,
+	// Start redelivers to the durable listeners the fires they had not
+	// received before the switch stopped, so a durable listener must be
+	// added before Start to receive them. Without a Persister it is the
+	// same as AddListenerForEvent.
+	AddDurableListener(listenerID, event string, cb EventCallback) error
+
+	// AddNTimesListener subscribes cb to event for the next n fires only:
+	// once cb has received n of them, even concurrently, the subscription
+	// is removed, and the fires racing with the last one skip it.
+	AddNTimesListener(listenerID, event string, n int, cb EventCallback) error
+
+	// ReplaceListenerCallback atomically replaces the callback of a listener
+	// for an event: every fire is delivered either to the old or to the new
+	// callback, exactly once. A durable subscription stays durable. It
+	// returns ErrListenerNotSubscribed if the listener is not subscribed to
+	// the event.
+	ReplaceListenerCallback(listenerID, event string, cb EventCallback) error
+
+	// AddTransformer adds fn to the transformers of event, which rewrite
+	// the data of its fires, e.g. to enrich it with the current height,
+	// before any listener sees it: the transformers run in the order they
+	// were added, each on the output of the previous one, and the
+	// listeners receive the output of the last. If a transformer fails,
+	// the error is logged and the fire is aborted. Fires ignored before,
+	// e.g. those of disabled events, are not transformed.
+	AddTransformer(event string, fn Transformer)
+
+	// FireEventParallel invokes the callbacks of all listeners of event
+	// concurrently and waits for them to return. The first error cancels
+	// the context passed to the remaining callbacks and is returned.
+	// Callbacks must be safe to run concurrently with each other.
+	FireEventParallel(ctx context.Context, event string, data EventData) error
+
+	// SetDeadLetterEvent sets the event that fires without listeners are
+	// redelivered under, wrapped in a DeadLetter. An empty event disables
+	// dead-lettering, which is the default.
+	SetDeadLetterEvent(event string)
+
+	// SetErrorHandler sets the handler of the errors returned by callbacks,
+	// whichever way they were invoked. It runs on the goroutine that invoked
+	// the callback, once the callback has returned, and a panic in it is
+	// recovered and logged. A nil handler restores the default, which logs
+	// the errors.
+	SetErrorHandler(handler ErrorHandler)
+
+	// FireEventCounted fires like FireEvent and reports how many listeners
+	// received the event and how many were skipped, e.g. because a channel
+	// subscription dropped it or ctx was done before reaching it. Fires
+	// dropped by the fire interceptor report neither.
+	FireEventCounted(ctx context.Context, event string, data EventData) (delivered, skipped int)
+
+	// FireEventAwait fires event to all its listeners and returns once the
+	// callback of listenerID has returned, with the error it returned. The
+	// other listeners are invoked in the background and do not delay it;
+	// like the fires of FireEventNonBlocking, they are delivered with the
+	// context of the switch unless it was created with WithInheritContext.
+	// It returns ErrListenerNotSubscribed if listenerID is not subscribed to
+	// event and ErrNotDelivered if the event was skipped for it.
+	FireEventAwait(ctx context.Context, event string, data EventData, listenerID string) error
+
+	// FireEventFirstSuccess invokes the listeners of event one at a time,
+	// in the order they subscribed, until one of them returns nil, and
+	// returns nil; the remaining listeners do not receive the event. The
+	// order of subscription thus decides which of several capable
+	// listeners handles it, so fallbacks must subscribe after the
+	// listeners they back up. If every listener fails, their errors are
+	// returned as ListenerErrors. It returns ErrNotDelivered if no listener
+	// handled the event nor failed, e.g. because there were none.
+	FireEventFirstSuccess(ctx context.Context, event string, data EventData) error
+
+	// FireEventNonBlocking queues the fire for delivery by the worker pool of
+	// the switch and returns without waiting for it. It reports false, and
+	// the fire is dropped, if the queue is full or the switch is shutting
+	// down or has stopped. Queued fires are delivered once the switch has
+	// started. The callbacks
+	// receive a context carrying the values of ctx that is cancelled when
+	// the switch stops, not when ctx is; see WithInheritContext.
+	FireEventNonBlocking(ctx context.Context, event string, data EventData) bool
+
+	// DeadLetters returns the deliveries of FireEventNonBlocking that failed
+	// for good, oldest first, see WithRetries. Only the latest 256 are
+	// retained. It returns nil unless the switch was created with
+	// WithRetries.
+	DeadLetters() []FailedEvent
+
+	// PendingEvents returns the fires queued by FireEventNonBlocking that no
+	// worker has picked up yet, in the order they were queued. It is a
+	// snapshot and leaves the queue untouched. QueueLen returns the number
+	// of such fires and QueueCap the number the queue holds when full, see
+	// WithWorkerPool, so that producers can shed load before fires are
+	// rejected. Both are cheap enough to be called before every fire.
+	PendingEvents() []NamedEvent
+	QueueLen() int
+	QueueCap() int
+
+	// FireFromChannel fires each value received on ch as event until ch is
+	// closed or ctx is done. It blocks until then, firing on the calling
+	// goroutine.
+	FireFromChannel(ctx context.Context, event string, ch <-chan EventData)
+
+	// FireEventWithID fires like FireEvent with the correlation ID corrID
+	// attached to the context passed to the callbacks, where it can be read
+	// with CorrelationIDFromContext.
+	FireEventWithID(ctx context.Context, event string, data EventData, corrID string) error
+
+	// FireEventDetached fires like FireEvent, but with the context of the
+	// switch, see Context, rather than one of the caller: the callbacks keep
+	// running however long the caller waits, until the switch stops.
+	FireEventDetached(event string, data EventData)
+
+	// FireAtomic fires the events of pairs one after the other, in order,
+	// as a single logical change, and returns the ID it assigned to it. The
+	// callbacks read the ID, along with the position of the fire in the
+	// change, with FireGroupFromContext, so that a listener of several of
+	// the events can tell which fires belong together; the fires of other
+	// goroutines may still be interleaved with them. It returns zero, and
+	// fires nothing, if pairs is empty or the switch rejects fires.
+	FireAtomic(ctx context.Context, pairs []EventPair) uint64
+
+	// FireEventAt fires like FireEvent at the logical time at, e.g. when
+	// replaying historical events. The switch records the fire at that time
+	// rather than at the current one, and the callbacks read it with
+	// FireTimeFromContext. Like a correlation ID, it is carried along by the
+	// fires made with the context the callbacks receive.
+	FireEventAt(ctx context.Context, event string, data EventData, at time.Time) error
+
+	// FireEventFrom fires like FireEvent, tagging the fire with the
+	// subsystem that produced it so that the listeners of an event fired
+	// by several producers can tell them apart. The callbacks read the
+	// source with SourceFromContext. Like a correlation ID, it is carried
+	// along by the fires made with the context the callbacks receive,
+	// unless they fire with FireEventFrom themselves.
+	FireEventFrom(ctx context.Context, event string, data EventData, source string) error
+
+	// Shutdown delivers the fires queued by FireEventNonBlocking, stops the
+	// switch, rejects further fires and waits, within ctx, for the fires in
+	// progress to complete. It returns ErrFiresDropped if queued fires were
+	// dropped instead.
+	Shutdown(ctx context.Context) error
+
+	// DisableEvent turns fires of event into no-ops until EnableEvent is
+	// called. Listeners of the event stay subscribed.
+	DisableEvent(event string)
+	EnableEvent(event string)
+
+	// SuspendListener makes fires skip the listener, whatever the event,
+	// until ResumeListener is called. The listener stays subscribed, and
+	// the fires it skips while suspended are not delivered later. Removing
+	// the listener resumes it.
+	SuspendListener(listenerID string)
+	ResumeListener(listenerID string)
+
+	// SetContextFunc makes every invocation of the callbacks of a listener
+	// receive the context returned by fn, e.g. to attach the scoped logger
+	// of the listener once and for all. fn runs as part of the callback: it
+	// is covered by WithPanicEvents. A nil fn removes the function, as does
+	// removing the listener.
+	SetContextFunc(listenerID string, fn ContextFunc)
+
+	// SetMaxPayloadSize limits the size of the data fires of event may
+	// carry to bytes, as measured by the sizer set with WithPayloadSizer.
+	// Larger fires are dropped. A limit that is not positive removes the
+	// limit. Limits are ignored unless the switch has a sizer.
+	SetMaxPayloadSize(event string, bytes int)
+
+	// Done returns a channel that is closed when the switch stops.
+	Done() <-chan struct{}
+
+	// Context returns a context that is cancelled when the switch stops.
+	// Once the switch is started, the context is derived from the one passed
+	// to Start; before that it carries no values or deadline.
+	Context() context.Context
+
+	// SubscribeChan subscribes listenerID to event and returns a channel on
+	// which the fired data is delivered according to opts.
+	SubscribeChan(listenerID, event string, opts ChanOptions) (<-chan EventData, error)
+
+	// RecvEvent receives the next event from ch, a channel returned by
+	// SubscribeChan. It returns the data and true once an event is
+	// received, and false once ch is closed. If ctx is done or the switch
+	// stops first, it returns ctx.Err() or ErrShutDown respectively; events
+	// already buffered in ch are still received before either is reported.
+	RecvEvent(ctx context.Context, ch <-chan EventData) (data EventData, ok bool, err error)
+
+	// MergeChan subscribes to all of events and returns a channel on which
+	// their fires are delivered in a single sequence, buffered up to
+	// capacity. The channel is closed when ctx is done or the switch stops.
+	MergeChan(ctx context.Context, events []string, capacity int) (<-chan NamedEvent, error)
+
+	// LatencyPercentiles returns the median, 95th and 99th percentiles of
+	// the time the recent fires of event took to be delivered to its
+	// listeners, as measured by the clock of the switch. They are always
+	// zero unless the switch was created with WithLatencyWindow.
+	LatencyPercentiles(event string) (p50, p95, p99 time.Duration)
+
+	// ListenerErrorRate returns the fraction of the recent invocations of
+	// the listener's callbacks that returned an error. It is always zero
+	// unless the switch was created with WithErrorRateWindow.
+	ListenerErrorRate(listenerID string) float64
+
+	// Stats returns a snapshot of the switch statistics.
+	Stats() Stats
+
+	// Report summarizes the activity of the switch since it was created. It
+	// is meant to be called once the switch has stopped, but is safe to call
+	// at any time.
+	Report() SwitchReport
+
+	// EventNames returns the sorted names of the events that have listeners.
+	EventNames() []string
+
+	// Listeners returns the sorted IDs of the listeners of event.
+	Listeners(event string) []string
+
+	// RangeEvents calls fn for each event that has listeners, in sorted
+	// order, passing the sorted IDs of the listeners subscribed to it. The
+	// traversal is performed under a read lock and stops early if fn returns
+	// false. fn must not call back into the switch.
+	RangeEvents(fn func(event string, listenerIDs []string) bool)
+
+	// ExportDOT writes the subscriptions of the switch to w as a Graphviz
+	// DOT graph, with an edge from each event to each of its listeners.
+	ExportDOT(w io.Writer) error
+
+	// SetHealthCheck sets the health check of a listener, which is removed
+	// along with it. A nil check removes the health check. It returns
+	// ErrUnknownListener if the switch knows no listener with that ID: it
+	// was never added or was removed with RemoveListener. A listener that
+	// unsubscribed from all its events with RemoveListenerForEvent is still
+	// known.
+	SetHealthCheck(listenerID string, check HealthCheck) error
+	// CheckListeners runs the health checks of all listeners concurrently
+	// and returns their results by listener ID, once they have all
+	// returned. Listeners without a health check are reported as healthy,
+	// with a nil error, and those whose check panicked as unhealthy, with
+	// an ErrHealthCheckPanicked.
+	CheckListeners(ctx context.Context) map[string]error
+
+	// CompareAndFire fires newData for event, like FireEvent, only if the
+	// data last fired for event with CompareAndFire equals expected
+	// according to eq, and reports whether it fired. Before the first such
+	// fire the last data is nil. Checking the last data and replacing it is
+	// atomic, so of concurrent calls expecting the same data at most one
+	// fires; eq runs under a lock and must not call the switch. The data is
+	// replaced only once the fire is admitted: a fire rejected, say because
+	// the event is disabled or a transformer failed, reports false and keeps
+	// the last data, so admission, including the delay of the fire
+	// interceptor, happens under the lock as well. The delivery itself
+	// happens once the lock is released, so that callbacks may call
+	// CompareAndFire in turn. It returns ErrShutDown once the switch is
+	// shut down.
+	CompareAndFire(ctx context.Context, event string, expected, newData EventData,
+		eq func(a, b EventData) bool) (bool, error)
+
+	// Begin starts a transaction batching listener changes, which are
+	// applied together by its Commit.
+	Begin() *Tx
+
+	// UseForEvent wraps the callbacks of the listeners of event in mw when
+	// they are invoked for a fire of event, e.g. to validate its data. The
+	// middleware added first is the outermost. Fires of other events are
+	// not affected, nor are the fires of event in progress.
+	UseForEvent(event string, mw Middleware)
+
+	// EverFired reports whether event was fired at least once since the
+	// switch was created, whether or not the fire reached any listener.
+	// It always reports false unless the switch was created with
+	// WithEverFired.
+	EverFired(event string) bool
+
+	// Alias makes the fires of oldEvent also reach the listeners of
+	// newEvent, e.g. while an event is being renamed and some producers
+	// still fire the old name. Aliases are resolved when firing and may be
+	// chained or form cycles, so two events can be aliased both ways; each
+	// listener receives a given fire at most once.
+	Alias(oldEvent, newEvent string)
 }
 
 type eventSwitch struct {
 	service.BaseService
+	logger log.Logger
+	name   string
 
 	mtx        sync.RWMutex
 	eventCells map[string]*eventCell
 	listeners  map[string]*eventListener
+	chanSubs   map[subKey]*chanSub
+	failover   map[subKey]*failoverGroup
+	batching   map[subKey]*batchingListener
+	aliases    map[string][]string
+	// durable maps events to their durable listeners.
+	durable map[string]map[string]struct{}
+	// failoverMtx serializes AddFailoverListener.
+	failoverMtx sync.Mutex
+
+	lagSampleInterval time.Duration
+	lagSamples        int
+	logSampleInterval time.Duration
+
+	clock       Clock
+	stats       switchStats
+	groupSeq    uint64 // atomic, see FireAtomic
+	stopSeq     uint64 // atomic, see AddListenerWithStopPriority
+	fires       fireTracker
+	interceptor FireInterceptor
+	errorRates  *errorRates
+	latencies   *fireLatencies
+	breakers    *breakers
+	weak        weakListeners
+	deadLetter  string
+	disabled    disabledEvents
+	suspended   suspendedListeners
+	ctxFuncs    contextFuncs
+	middleware  eventMiddleware
+	transforms  eventTransformers
+	lastValues  lastValues
+	fired       *firedEvents
+	callers     *recentFires
+	deadlocks   *deadlockDetector
+	sizer       PayloadSizer
+	maxPayload  payloadLimits
+	pool        workerPool
+	retries     *retries
+
+	// persister persists the fires of events with durable listeners;
+	// persistMtx guards the number of the last fire persisted.
+	persister  Persister
+	persistMtx sync.Mutex
+	persistSeq uint64
+
+	// errorHandler handles the errors of callbacks; it is guarded by mtx.
+	errorHandler ErrorHandler
+
+	// slowCallback is the duration above which a callback is logged as
+	// slow; zero disables the check.
+	slowCallback time.Duration
+	// fireTimeout bounds the callbacks invoked with a context without a
+	// deadline; zero leaves them unbounded.
+	fireTimeout time.Duration
+	// failoverTimeout bounds each member of a failover group.
+	failoverTimeout time.Duration
+
+	lifecycleEvents  bool
+	clonePerListener bool
+	panicEvents      bool
+	inheritContext   bool
+
+	// parent receives the fires of a child switch, see NewChildEventSwitch.
+	parent Fireable
+
+	// ctx is cancelled and done is closed when the switch stops.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	// started is closed when the switch starts.
+	started chan struct{}
 }
 
-func NewEventSwitch(logger log.Logger) EventSwitch {
+// NewEventSwitch creates a new EventSwitch configured with the given options.
+func NewEventSwitch(logger log.Logger, opts ...Option) EventSwitch {
 	evsw := &eventSwitch{
+		logger:     logger,
 		eventCells: make(map[string]*eventCell),
 		listeners:  make(map[string]*eventListener),
+		chanSubs:   make(map[subKey]*chanSub),
+		failover:   make(map[subKey]*failoverGroup),
+		batching:   make(map[subKey]*batchingListener),
+		aliases:    make(map[string][]string),
+		durable:    make(map[string]map[string]struct{}),
+
+		lagSampleInterval: defaultLagSampleInterval,
+		lagSamples:        defaultLagSamples,
+		logSampleInterval: defaultLogSampleInterval,
+		clock:             realClock{},
+		fires:             newFireTracker(),
+		pool:              newWorkerPool(defaultPoolWorkers, defaultPoolQueue),
+
+		done:    make(chan struct{}),
+		started: make(chan struct{}),
+	}
+	evsw.ctx, evsw.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(evsw)
+	}
+	// Whichever of WithLatencyWindow, WithEverFired and WithMaxCachedEvents
+	// came first.
+	if evsw.latencies != nil {
+		evsw.latencies.rings.max = evsw.lastValues.values.max
+	}
+	if evsw.fired != nil {
+		evsw.fired.events.max = evsw.lastValues.values.max
+	}
+	if evsw.name != "" {
+		logger = logger.With("switch", evsw.name)
+		evsw.logger = logger
+	}
+	if evsw.logSampleInterval > 0 {
+		evsw.logger = newSamplingLogger(evsw.logger, evsw.clock, evsw.logSampleInterval)
 	}
 	evsw.BaseService = *service.NewBaseService(logger, "EventSwitch", evsw)
 	return evsw
 }
 
 func (evsw *eventSwitch) OnStart(ctx context.Context) error {
+	var unacked []NamedEvent
+	if evsw.persister != nil {
+		var err error
+		if unacked, err = evsw.recoverPersisted(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	evsw.mtx.Lock()
+	// The context handed out before Start must be cancelled on stop as well.
+	cancelPrev := evsw.cancel
+	evsw.ctx = ctx
+	evsw.cancel = func() {
+		cancel()
+		cancelPrev()
+	}
+	evsw.mtx.Unlock()
+
+	// The fires missed before a restart go before those queued since.
+	evsw.replay(ctx, unacked)
+	go evsw.monitorLag(ctx)
+	evsw.pool.start(ctx, evsw)
+	close(evsw.started)
+
+	evsw.fireLifecycle(ctx, SwitchStarted)
 	return nil
 }
 
-func (evsw *eventSwitch) OnStop() {}
+func (evsw *eventSwitch) OnStop() {
+	evsw.mtx.RLock()
+	ctx, cancel := evsw.ctx, evsw.cancel
+	evsw.mtx.RUnlock()
+
+	evsw.fireLifecycle(ctx, SwitchStopping)
+	evsw.runStopHooks(ctx)
+	cancel()
+	close(evsw.done)
+	evsw.fireLifecycle(context.Background(), SwitchStopped)
+}
+
+func (evsw *eventSwitch) Done() <-chan struct{} {
+	return evsw.done
+}
+
+func (evsw *eventSwitch) Context() context.Context {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+	return evsw.ctx
+}
 
 func (evsw *eventSwitch) AddListenerForEvent(listenerID, eventValue string, cb EventCallback) error {
+	return evsw.addListener(listenerID, eventValue, cb, subState{})
+}
+
+func (evsw *eventSwitch) AddListenerForEventBlocking(
+	ctx context.Context,
+	listenerID, eventValue string,
+	cb EventCallback,
+) error {
+	if err := evsw.addListener(listenerID, eventValue, cb, subState{}); err != nil {
+		return err
+	}
+	select {
+	case <-evsw.started:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// subState is the state backing the callback of a subscription, kept by
+// the switch until the subscription is removed or replaced.
+type subState struct {
+	// sub is the channel subscription backing the callback, if any.
+	sub *chanSub
+	// bl is the batching listener backing the callback, if any.
+	bl *batchingListener
+	// durable marks the subscription as durable, see AddDurableListener.
+	durable bool
+}
+
+// close closes the channel subscription or batching listener, if any.
+func (st subState) close() {
+	if st.sub != nil {
+		st.sub.close()
+	}
+	if st.bl != nil {
+		st.bl.close()
+	}
+}
+
+// addListener registers cb for the listener and event, along with the state
+// backing it. The state of a previous subscription of the same listener to
+// the same event is closed. Both are registered under a single lock, so that
+// a concurrent removal of the subscription finds its state.
+func (evsw *eventSwitch) addListener(listenerID, eventValue string, cb EventCallback, state subState) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+
 	// Get/Create eventCell and listener.
 	evsw.mtx.Lock()
 
@@ -93,34 +707,164 @@ func (evsw *eventSwitch) AddListenerForEvent(listenerID, eventValue string, cb E
 		evsw.listeners[listenerID] = listener
 	}
 
-	evsw.mtx.Unlock()
-
 	if err := listener.AddEvent(eventValue); err != nil {
+		evsw.mtx.Unlock()
 		return err
 	}
 
-	eventCell.AddListener(listenerID, cb)
+	eventCell.AddListener(listener, cb)
+
+	key := subKey{listenerID: listenerID, event: eventValue}
+	prev := evsw.takeSubState(key)
+	evsw.forgetDurable(key)
+	if state.sub != nil {
+		evsw.chanSubs[key] = state.sub
+	}
+	if state.bl != nil {
+		evsw.batching[key] = state.bl
+	}
+	if state.durable {
+		evsw.markDurable(key)
+	}
+	evsw.mtx.Unlock()
+
+	prev.close()
 	return nil
 }
 
+// detach forgets the state kept for the subscription identified by key,
+// closing its channel subscription or batching listener if any.
+func (evsw *eventSwitch) detach(key subKey) {
+	evsw.mtx.Lock()
+	st := evsw.takeSubState(key)
+	evsw.forgetDurable(key)
+	evsw.mtx.Unlock()
+
+	st.close()
+}
+
+// takeSubState forgets the state backing the callback of the subscription
+// identified by key and returns the part of it to close; it is called with
+// mtx held. Whether the subscription is durable is not part of it, since a
+// durable subscription stays durable when its callback is replaced.
+func (evsw *eventSwitch) takeSubState(key subKey) subState {
+	st := subState{sub: evsw.chanSubs[key], bl: evsw.batching[key]}
+	delete(evsw.chanSubs, key)
+	delete(evsw.failover, key)
+	delete(evsw.batching, key)
+	return st
+}
+
 func (evsw *eventSwitch) RemoveListener(listenerID string) {
+	evsw.removeListener(listenerID)
+}
+
+// removeListener removes the listener and returns it, or nil if there is no
+// listener with that ID.
+func (evsw *eventSwitch) removeListener(listenerID string) *eventListener {
 	// Get and remove listener.
 	evsw.mtx.RLock()
 	listener := evsw.listeners[listenerID]
 	evsw.mtx.RUnlock()
 	if listener == nil {
-		return
+		return nil
 	}
 
 	evsw.mtx.Lock()
 	delete(evsw.listeners, listenerID)
 	evsw.mtx.Unlock()
 
+	evsw.suspended.remove(listenerID)
+	evsw.ctxFuncs.remove(listenerID)
+
+	if evsw.errorRates != nil {
+		evsw.errorRates.remove(listenerID)
+	}
+	if evsw.breakers != nil {
+		evsw.breakers.remove(listenerID)
+	}
+
 	// Remove callback for each event.
 	listener.SetRemoved()
 	for _, event := range listener.GetEvents() {
 		evsw.RemoveListenerForEvent(event, listenerID)
 	}
+	return listener
+}
+
+func (evsw *eventSwitch) RemoveAllListeners() {
+	var keys []subKey
+
+	evsw.mtx.Lock()
+	listeners := evsw.listeners
+	evsw.listeners = make(map[string]*eventListener)
+	for event, eventCell := range evsw.eventCells {
+		for _, listenerID := range eventCell.RemoveAll() {
+			keys = append(keys, subKey{listenerID: listenerID, event: event})
+		}
+	}
+	evsw.eventCells = make(map[string]*eventCell)
+	evsw.mtx.Unlock()
+
+	for listenerID, listener := range listeners {
+		listener.SetRemoved()
+		evsw.suspended.remove(listenerID)
+		evsw.ctxFuncs.remove(listenerID)
+		if evsw.errorRates != nil {
+			evsw.errorRates.remove(listenerID)
+		}
+		if evsw.breakers != nil {
+			evsw.breakers.remove(listenerID)
+		}
+	}
+	for _, key := range keys {
+		evsw.detach(key)
+	}
+}
+
+func (evsw *eventSwitch) RemoveListenersForEventPrefix(prefix string) int {
+	var keys []subKey
+
+	evsw.mtx.Lock()
+	for event, eventCell := range evsw.eventCells {
+		if !strings.HasPrefix(event, prefix) {
+			continue
+		}
+
+		for _, listenerID := range eventCell.RemoveAll() {
+			keys = append(keys, subKey{listenerID: listenerID, event: event})
+		}
+		delete(evsw.eventCells, event)
+	}
+	evsw.mtx.Unlock()
+
+	for _, key := range keys {
+		evsw.detach(key)
+	}
+	return len(keys)
+}
+
+func (evsw *eventSwitch) ReplaceListenerCallback(listenerID, event string, cb EventCallback) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+
+	evsw.mtx.RLock()
+	eventCell := evsw.eventCells[event]
+	evsw.mtx.RUnlock()
+
+	if eventCell == nil || !eventCell.ReplaceListener(listenerID, cb) {
+		return ErrListenerNotSubscribed{listenerID: listenerID, event: event}
+	}
+
+	// A channel subscription is no longer fed once its callback is replaced,
+	// while a durable subscription stays durable.
+	evsw.mtx.Lock()
+	st := evsw.takeSubState(subKey{listenerID: listenerID, event: event})
+	evsw.mtx.Unlock()
+
+	st.close()
+	return nil
 }
 
 func (evsw *eventSwitch) RemoveListenerForEvent(event string, listenerID string) {
@@ -136,6 +880,9 @@ func (evsw *eventSwitch) RemoveListenerForEvent(event string, listenerID string)
 	// Remove listenerID from eventCell
 	numListeners := eventCell.RemoveListener(listenerID)
 
+	// Close the channel subscription, if any.
+	evsw.detach(subKey{listenerID: listenerID, event: event})
+
 	// Maybe garbage collect eventCell.
 	if numListeners == 0 {
 		// Lock again and double check.
@@ -149,18 +896,391 @@ func (evsw *eventSwitch) RemoveListenerForEvent(event string, listenerID string)
 	}
 }
 
-func (evsw *eventSwitch) FireEvent(ctx context.Context, event string, data EventData) {
-	// Get the eventCell
+func (evsw *eventSwitch) FireEvent(ctx context.Context, event string, data EventData) error {
+	if !evsw.fires.begin() {
+		return nil
+	}
+	defer evsw.fires.end()
+
+	evsw.fire(ctx, event, data)
+	return ctx.Err()
+}
+
+// fire delivers a fire admitted by the fire tracker.
+func (evsw *eventSwitch) fire(ctx context.Context, event string, data EventData) {
+	prepared, err := evsw.admitFire(ctx, event, data)
+	if err != nil {
+		evsw.logTransformError(event, err)
+		return
+	}
+	evsw.fireAdmitted(ctx, event, data, prepared)
+}
+
+// fireAdmitted delivers a fire of data admitted by admitFire, which
+// returned prepared, and bubbles it up to the parent of the switch.
+func (evsw *eventSwitch) fireAdmitted(ctx context.Context, event string, data, prepared EventData) {
+	evsw.deliver(ctx, event, prepared)
+
+	// The parent admits, and transforms, the original data on its own.
+	if evsw.parent != nil {
+		evsw.bubble(ctx, event, data)
+	}
+}
+
+// deliver delivers an admitted fire to the listeners of event.
+func (evsw *eventSwitch) deliver(ctx context.Context, event string, data EventData) {
+	ctx, d := evsw.beginDelivery(ctx, event, data)
+
+	// Most events have a single listener: invoke it without building a
+	// snapshot of the callbacks.
+	if lc, ok := evsw.singleCallback(event); ok {
+		evsw.stats.recordFire(1)
+		if ctx.Err() == nil && !d.skips(lc.listenerID) {
+			_ = evsw.invoke(ctx, lc, data)
+		}
+	} else {
+		// Fire event for all listeners of the event
+		callbacks, prepared := evsw.collectCallbacks(event, data)
+		evsw.dispatch(ctx, d.filter(callbacks), prepared)
+	}
+	evsw.endDelivery(ctx, event, d)
+}
+
+// delivery is the delivery of an admitted fire, see beginDelivery.
+type delivery struct {
+	start time.Time
+	// seq is the number of the persisted fire, zero if it was not
+	// persisted.
+	seq uint64
+	// unpersisted holds the durable listeners of a fire that could not be
+	// persisted, which it is not delivered to.
+	unpersisted map[string]struct{}
+}
+
+// skips reports whether the delivery skips the listener.
+func (d delivery) skips(listenerID string) bool {
+	_, ok := d.unpersisted[listenerID]
+	return ok
+}
+
+// filter returns the callbacks the delivery is for.
+func (d delivery) filter(callbacks []listenerCallback) []listenerCallback {
+	if len(d.unpersisted) == 0 {
+		return callbacks
+	}
+	kept := make([]listenerCallback, 0, len(callbacks))
+	for _, lc := range callbacks {
+		if !d.skips(lc.listenerID) {
+			kept = append(kept, lc)
+		}
+	}
+	return kept
+}
+
+// beginDelivery persists an admitted fire of event, if it has durable
+// listeners, and returns the context to deliver it with. If the fire could
+// not be persisted, the delivery skips the durable listeners; the others
+// still receive it. The delivery must be ended with endDelivery once the
+// callbacks returned.
+func (evsw *eventSwitch) beginDelivery(ctx context.Context, event string, data EventData) (context.Context, delivery) {
+	var d delivery
+	if evsw.latencies != nil {
+		d.start = evsw.clock.Now()
+	}
+	if evsw.persister != nil {
+		ctx, d.seq, d.unpersisted = evsw.persist(ctx, event, data)
+	}
+	return ctx, d
+}
+
+// endDelivery records the latency of the delivery, and acknowledges the fire
+// if it was persisted, unless ctx, the context returned by beginDelivery,
+// was done before the fire could reach every listener.
+func (evsw *eventSwitch) endDelivery(ctx context.Context, event string, d delivery) {
+	if evsw.latencies != nil {
+		evsw.latencies.record(event, evsw.clock.Now().Sub(d.start))
+	}
+	if d.seq != 0 && ctx.Err() == nil {
+		evsw.ack(d.seq)
+	}
+}
+
+// dispatch invokes callbacks one after the other and returns how many of
+// them received data and how many skipped it.
+func (evsw *eventSwitch) dispatch(ctx context.Context, callbacks []listenerCallback, data EventData) (delivered, skipped int) {
+	for i, lc := range callbacks {
+		// Stop promptly once the caller has given up on the fire.
+		if ctx.Err() != nil {
+			return delivered, skipped + len(callbacks) - i
+		}
+
+		// should we log or abort on error here?
+		err := evsw.invoke(ctx, lc, data)
+		switch {
+		case errors.Is(err, errEventDropped):
+			skipped++
+		case errors.Is(err, ErrStopPropagation):
+			return delivered + 1, skipped + len(callbacks) - i - 1
+		default:
+			delivered++
+		}
+	}
+	return delivered, skipped
+}
+
+// preparedFire is a fire admitted and begun by prepareFire, for the fire
+// methods that invoke the callbacks themselves.
+type preparedFire struct {
+	// ctx is the context to invoke the callbacks with.
+	ctx       context.Context
+	callbacks []listenerCallback
+	data      EventData
+	delivery  delivery
+}
+
+// prepareFire admits the fire, begins its delivery, persisting it if need
+// be, and returns the snapshot of listener callbacks it must be delivered
+// to, along with the data to deliver. Fires without listeners are
+// redirected to the dead-letter event. It returns the error of admitFire if
+// the fire is not admitted. A prepared fire must be finished with
+// finishFire once its callbacks returned.
+func (evsw *eventSwitch) prepareFire(ctx context.Context, event string, data EventData) (preparedFire, error) {
+	admitted, err := evsw.admitFire(ctx, event, data)
+	if err != nil {
+		return preparedFire{}, err
+	}
+	ctx, d := evsw.beginDelivery(ctx, event, admitted)
+	callbacks, prepared := evsw.collectCallbacks(event, admitted)
+	return preparedFire{ctx: ctx, callbacks: d.filter(callbacks), data: prepared, delivery: d}, nil
+}
+
+// finishFire ends the delivery of a prepared fire of event and bubbles
+// data, as given to prepareFire, up to the parent of the switch, like fire
+// does.
+func (evsw *eventSwitch) finishFire(ctx context.Context, event string, data EventData, pf preparedFire) {
+	evsw.endDelivery(pf.ctx, event, pf.delivery)
+	if evsw.parent != nil {
+		evsw.bubble(ctx, event, data)
+	}
+}
+
+// errFireRejected is returned by admitFire for a fire that is ignored
+// because its event is disabled, its data is oversized or the interceptor
+// turned it down.
+var errFireRejected = errors.New("fire rejected")
+
+// admitFire checks whether the fire of event is to be delivered and returns
+// the data to deliver, as transformed by the transformers of the event. It
+// returns errFireRejected if the fire is to be ignored, or the error of the
+// transformer that aborted it.
+func (evsw *eventSwitch) admitFire(ctx context.Context, event string, data EventData) (EventData, error) {
+	if evsw.fired != nil {
+		evsw.fired.record(event)
+	}
+	if evsw.callers != nil {
+		evsw.recordCaller(ctx, event)
+	}
+
+	if evsw.isDisabled(event) {
+		evsw.stats.recordDisabled()
+		return nil, errFireRejected
+	}
+	if evsw.sizer != nil && evsw.isOversized(event, data) {
+		evsw.stats.recordOversized()
+		return nil, errFireRejected
+	}
+
+	if evsw.interceptor != nil {
+		proceed, delay := evsw.interceptor(event, data)
+		if !proceed {
+			return nil, errFireRejected
+		}
+		if delay > 0 {
+			timer := evsw.clock.NewTimer(delay)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, errFireRejected
+			}
+		}
+	}
+	return evsw.transform(event, data)
+}
+
+// collectCallbacks returns the callbacks an admitted fire of event is
+// dispatched to, and the data they receive.
+func (evsw *eventSwitch) collectCallbacks(event string, data EventData) ([]listenerCallback, EventData) {
+	callbacks := evsw.callbacks(event)
+	evsw.stats.recordFire(len(callbacks))
+
+	if len(callbacks) == 0 {
+		if deadLetter := evsw.deadLetterEvent(); deadLetter != "" && deadLetter != event {
+			return evsw.callbacks(deadLetter), DeadLetter{OriginalEvent: event, Data: data}
+		}
+	}
+	return callbacks, data
+}
+
+// singleCallback returns the callback of the only listener of event, if it
+// has exactly one and no aliases.
+func (evsw *eventSwitch) singleCallback(event string) (listenerCallback, bool) {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	eventCell := evsw.eventCells[event]
+	if eventCell == nil || len(evsw.aliases[event]) > 0 {
+		return listenerCallback{}, false
+	}
+	eventCell.mtx.RLock()
+	defer eventCell.mtx.RUnlock()
+	if len(eventCell.order) != 1 {
+		return listenerCallback{}, false
+	}
+	listenerID := eventCell.order[0]
+	cl := eventCell.listeners[listenerID]
+	return listenerCallback{listenerID: listenerID, event: event, cb: cl.cb, listener: cl.listener}, true
+}
+
+// callbacks returns a snapshot of the callbacks of the listeners of event.
+func (evsw *eventSwitch) callbacks(event string) []listenerCallback {
+	// The cells are read under evsw.mtx so that a fire never observes a
+	// transaction half-committed, see Tx.Commit.
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	if len(evsw.aliases[event]) > 0 {
+		return aliasedCallbacks(evsw.aliasedCells(event))
+	}
+	eventCell := evsw.eventCells[event]
+	if eventCell == nil {
+		return nil
+	}
+	callbacks := eventCell.Callbacks()
+	for i := range callbacks {
+		callbacks[i].event = event
+	}
+	return callbacks
+}
+
+// invoke runs the callback of a listener and records its outcome.
+func (evsw *eventSwitch) invoke(ctx context.Context, lc listenerCallback, data EventData) error {
+	if c, ok := data.(Clonable); ok && evsw.clonePerListener {
+		data = c.Clone()
+	}
+
+	if evsw.suspended.has(lc.listenerID) {
+		return errEventDropped
+	}
+	if !lc.listener.beginInvoke() {
+		return errEventDropped
+	}
+	defer lc.listener.endInvoke()
+
+	if evsw.deadlocks != nil {
+		defer evsw.deadlocks.exit(evsw.deadlocks.enter(lc.listenerID))
+	}
+
+	if evsw.breakers != nil && !evsw.breakers.allow(lc.listenerID, evsw.clock.Now()) {
+		return errEventDropped
+	}
+
+	if chain := evsw.middleware.get(lc.event); chain != nil {
+		lc = withMiddleware(lc, chain)
+	}
+	if fn := evsw.ctxFuncs.get(lc.listenerID); fn != nil {
+		lc = withContextFunc(lc, fn)
+	}
+	if evsw.fireTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, evsw.fireTimeout)
+			defer cancel()
+		}
+	}
+
+	evsw.stats.startCallback()
+	var start time.Time
+	if evsw.slowCallback > 0 {
+		start = evsw.clock.Now()
+	}
+	var err error
+	if evsw.panicEvents {
+		err = evsw.callRecovering(ctx, lc, data)
+	} else {
+		err = lc.cb(ctx, data)
+	}
+	if evsw.slowCallback > 0 {
+		if elapsed := evsw.clock.Now().Sub(start); elapsed > evsw.slowCallback {
+			evsw.logger.Info("slow event callback",
+				"listener", lc.listenerID, "event", lc.event, "duration", elapsed)
+		}
+	}
+	// A dropped event is a delivery outcome and a stopped propagation a
+	// decision of the callback, neither is a callback failure.
+	dropped := errors.Is(err, errEventDropped)
+	failure := err
+	if dropped || errors.Is(err, ErrStopPropagation) {
+		failure = nil
+	}
+	evsw.stats.endCallback(failure)
+	if evsw.errorRates != nil && !dropped {
+		evsw.errorRates.record(lc.listenerID, failure != nil)
+	}
+	if evsw.breakers != nil {
+		evsw.breakers.record(lc.listenerID, failure != nil, evsw.clock.Now())
+	}
+	if failure != nil {
+		evsw.handleError(lc.listenerID, lc.event, failure)
+	}
+	return err
+}
+
+func (evsw *eventSwitch) EventNames() []string {
+	var events []string
+	evsw.RangeEvents(func(event string, _ []string) bool {
+		events = append(events, event)
+		return true
+	})
+	return events
+}
+
+func (evsw *eventSwitch) Listeners(event string) []string {
 	evsw.mtx.RLock()
 	eventCell := evsw.eventCells[event]
 	evsw.mtx.RUnlock()
 
 	if eventCell == nil {
-		return
+		return nil
+	}
+	return eventCell.ListenerIDs()
+}
+
+func (evsw *eventSwitch) RangeEvents(fn func(event string, listenerIDs []string) bool) {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	events := make([]string, 0, len(evsw.eventCells))
+	for event := range evsw.eventCells {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		listenerIDs := evsw.eventCells[event].ListenerIDs()
+		if len(listenerIDs) == 0 {
+			continue
+		}
+		if !fn(event, listenerIDs) {
+			return
+		}
 	}
+}
 
-	// Fire event for all listeners in eventCell
-	eventCell.FireEvent(ctx, data)
+// subKey identifies the subscription of a listener to an event.
+type subKey struct {
+	listenerID string
+	event      string
 }
 
 //-----------------------------------------------------------------------------
@@ -168,43 +1288,107 @@ func (evsw *eventSwitch) FireEvent(ctx context.Context, event string, data Event
 // eventCell handles keeping track of listener callbacks for a given event.
 type eventCell struct {
 	mtx       sync.RWMutex
-	listeners map[string]EventCallback
+	listeners map[string]cellListener
+	// order holds the IDs of the listeners in the order they were added.
+	order []string
+}
+
+// cellListener is a listener of an event cell.
+type cellListener struct {
+	listener *eventListener
+	cb       EventCallback
 }
 
 func newEventCell() *eventCell {
 	return &eventCell{
-		listeners: make(map[string]EventCallback),
+		listeners: make(map[string]cellListener),
 	}
 }
 
-func (cell *eventCell) AddListener(listenerID string, cb EventCallback) {
+// AddListener adds a listener to the cell, or replaces its callback if it
+// already is in the cell, in which case it keeps its place in the order.
+func (cell *eventCell) AddListener(listener *eventListener, cb EventCallback) {
 	cell.mtx.Lock()
-	cell.listeners[listenerID] = cb
+	if _, ok := cell.listeners[listener.id]; !ok {
+		cell.order = append(cell.order, listener.id)
+	}
+	cell.listeners[listener.id] = cellListener{listener: listener, cb: cb}
 	cell.mtx.Unlock()
 }
 
+// ReplaceListener replaces the callback of an existing listener and reports
+// whether the listener was found.
+func (cell *eventCell) ReplaceListener(listenerID string, cb EventCallback) bool {
+	cell.mtx.Lock()
+	defer cell.mtx.Unlock()
+
+	cl, ok := cell.listeners[listenerID]
+	if !ok {
+		return false
+	}
+	cl.cb = cb
+	cell.listeners[listenerID] = cl
+	return true
+}
+
 func (cell *eventCell) RemoveListener(listenerID string) int {
 	cell.mtx.Lock()
-	delete(cell.listeners, listenerID)
+	if _, ok := cell.listeners[listenerID]; ok {
+		delete(cell.listeners, listenerID)
+		for i, id := range cell.order {
+			if id == listenerID {
+				cell.order = append(cell.order[:i], cell.order[i+1:]...)
+				break
+			}
+		}
+	}
 	numListeners := len(cell.listeners)
 	cell.mtx.Unlock()
 	return numListeners
 }
 
-func (cell *eventCell) FireEvent(ctx context.Context, data EventData) {
+// RemoveAll removes all listeners from the cell and returns their IDs.
+func (cell *eventCell) RemoveAll() []string {
+	cell.mtx.Lock()
+	defer cell.mtx.Unlock()
+
+	removed := cell.order
+	cell.listeners = make(map[string]cellListener)
+	cell.order = nil
+	return removed
+}
+
+// ListenerIDs returns the sorted IDs of the listeners in the cell.
+func (cell *eventCell) ListenerIDs() []string {
 	cell.mtx.RLock()
-	eventCallbacks := make([]EventCallback, 0, len(cell.listeners))
-	for _, cb := range cell.listeners {
-		eventCallbacks = append(eventCallbacks, cb)
+	listenerIDs := make([]string, 0, len(cell.listeners))
+	for listenerID := range cell.listeners {
+		listenerIDs = append(listenerIDs, listenerID)
 	}
 	cell.mtx.RUnlock()
+	sort.Strings(listenerIDs)
+	return listenerIDs
+}
 
-	for _, cb := range eventCallbacks {
-		if err := cb(ctx, data); err != nil {
-			// should we log or abort here?
-			continue
-		}
+// listenerCallback is the callback of a listener, as snapshotted by a fire.
+type listenerCallback struct {
+	listenerID string
+	event      string
+	cb         EventCallback
+	listener   *eventListener
+}
+
+// Callbacks returns a snapshot of the callbacks of all listeners in the cell,
+// in the order the listeners were added.
+func (cell *eventCell) Callbacks() []listenerCallback {
+	cell.mtx.RLock()
+	callbacks := make([]listenerCallback, 0, len(cell.order))
+	for _, listenerID := range cell.order {
+		cl := cell.listeners[listenerID]
+		callbacks = append(callbacks, listenerCallback{listenerID: listenerID, cb: cl.cb, listener: cl.listener})
 	}
+	cell.mtx.RUnlock()
+	return callbacks
 }
 
 //-----------------------------------------------------------------------------
@@ -212,11 +1396,23 @@ func (cell *eventCell) FireEvent(ctx context.Context, data EventData) {
 type EventCallback func(ctx context.Context, data EventData) error
 
 type eventListener struct {
+	// inFlight counts the invocations of the listener's callbacks in
+	// progress and halted is set by RemoveListenerWait; both are atomic,
+	// and inFlight comes first to be 64-bit aligned.
+	inFlight int64
+	halted   uint32
+	// idle is closed once inFlight drops to zero after halted was set. It
+	// is guarded by mtx and only created when RemoveListenerWait has to
+	// wait, so that invocations do not allocate.
+	idle chan struct{}
+
 	id string
 
-	mtx     sync.RWMutex
-	removed bool
-	events  []string
+	mtx         sync.RWMutex
+	removed     bool
+	events      []string
+	healthCheck HealthCheck
+	stopHook    *stopHook
 }
 
 func newEventListener(id string) *eventListener {
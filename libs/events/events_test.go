@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/service"
 )
 
 // TestAddListenerForEventFireOnce sets up an EventSwitch, subscribes a single
@@ -278,6 +281,100 @@ func TestAddAndRemoveListenerConcurrency(t *testing.T) {
 	}
 }
 
+func TestRemoveAllListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ch, err := evsw.SubscribeChan("chan", "other", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+
+	// the fire during which the listeners are removed still reaches all of
+	// them, since it snapshotted them beforehand
+	received := map[string]int{}
+	for _, id := range []string{"a", "b"} {
+		id := id
+		require.NoError(t, evsw.AddListenerForEvent(id, "event",
+			func(context.Context, EventData) error {
+				received[id]++
+				if len(received) == 1 {
+					evsw.RemoveAllListeners()
+				}
+				return nil
+			}))
+	}
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, received)
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, received)
+	assert.Empty(t, evsw.EventNames())
+	assert.Empty(t, drainChan(ch), "channel subscriptions are closed")
+
+	// removed listeners can subscribe again
+	require.NoError(t, evsw.AddListenerForEvent("a", "event",
+		func(context.Context, EventData) error {
+			received["a"]++
+			return nil
+		}))
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, 2, received["a"])
+}
+
+func TestRemoveAllListenersConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	const roundCount = 1000
+	var (
+		calls int64
+		wg    sync.WaitGroup
+	)
+	cb := func(context.Context, EventData) error {
+		atomic.AddInt64(&calls, 1)
+		return nil
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < roundCount; i++ {
+			evsw.FireEvent(ctx, fmt.Sprintf("event%d", i%10), nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < roundCount; i++ {
+			// errors are expected when racing with the removal
+			_ = evsw.AddListenerForEvent(fmt.Sprintf("listener%d", i%5), fmt.Sprintf("event%d", i%10), cb)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < roundCount/10; i++ {
+			evsw.RemoveAllListeners()
+		}
+	}()
+	wg.Wait()
+
+	// once the removal is no longer racing with additions, no listener is
+	// left to receive a fire
+	evsw.RemoveAllListeners()
+	before := atomic.LoadInt64(&calls)
+	for i := 0; i < 10; i++ {
+		evsw.FireEvent(ctx, fmt.Sprintf("event%d", i), nil)
+	}
+	assert.Equal(t, before, atomic.LoadInt64(&calls))
+	assert.Empty(t, evsw.EventNames())
+}
+
 // TestAddAndRemoveListener sets up an EventSwitch, subscribes a listener to
 // two events, fires a thousand integers for the first event, then unsubscribes
 // the listener and fires a thousand integers for the second event.
@@ -507,6 +604,546 @@ func TestRemoveListenersAsync(t *testing.T) {
 	}
 }
 
+func TestRangeEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	noop := func(context.Context, EventData) error { return nil }
+	require.NoError(t, evsw.AddListenerForEvent("listener2", "event1", noop))
+	require.NoError(t, evsw.AddListenerForEvent("listener1", "event1", noop))
+	require.NoError(t, evsw.AddListenerForEvent("listener1", "event2", noop))
+
+	got := map[string][]string{}
+	var order []string
+	evsw.RangeEvents(func(event string, listenerIDs []string) bool {
+		order = append(order, event)
+		got[event] = listenerIDs
+		return true
+	})
+	assert.Equal(t, []string{"event1", "event2"}, order)
+	assert.Equal(t, []string{"listener1", "listener2"}, got["event1"])
+	assert.Equal(t, []string{"listener1"}, got["event2"])
+
+	assert.Equal(t, []string{"event1", "event2"}, evsw.EventNames())
+	assert.Equal(t, []string{"listener1", "listener2"}, evsw.Listeners("event1"))
+	assert.Empty(t, evsw.Listeners("event3"))
+
+	// stop after the first event
+	calls := 0
+	evsw.RangeEvents(func(string, []string) bool {
+		calls++
+		return false
+	})
+	assert.Equal(t, 1, calls)
+
+	// removed events are no longer visited
+	evsw.RemoveListener("listener1")
+	evsw.RemoveListener("listener2")
+	evsw.RangeEvents(func(event string, _ []string) bool {
+		t.Errorf("unexpected event %q", event)
+		return true
+	})
+	assert.Empty(t, evsw.EventNames())
+	assert.Empty(t, evsw.Listeners("event1"))
+}
+
+func TestFireEventOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var order []string
+	add := func(id string) {
+		require.NoError(t, evsw.AddListenerForEvent(id, "event",
+			func(context.Context, EventData) error {
+				order = append(order, id)
+				return nil
+			}))
+	}
+	for _, id := range []string{"c", "a", "d", "b"} {
+		add(id)
+	}
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"c", "a", "d", "b"}, order)
+
+	// resubscribing keeps the place of a listener, removing it loses it
+	order = nil
+	add("a")
+	evsw.RemoveListenerForEvent("event", "d")
+	add("d")
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"c", "a", "b", "d"}, order)
+}
+
+func TestStopPropagation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var (
+		mtx   sync.Mutex
+		order []string
+	)
+	for _, id := range []string{"validate", "veto", "apply"} {
+		id := id
+		require.NoError(t, evsw.AddListenerForEvent(id, "event",
+			func(_ context.Context, data EventData) error {
+				mtx.Lock()
+				order = append(order, id)
+				mtx.Unlock()
+				if id == "veto" && data == "invalid" {
+					return fmt.Errorf("rejected: %w", ErrStopPropagation)
+				}
+				return nil
+			}))
+	}
+
+	delivered, skipped := evsw.FireEventCounted(ctx, "event", "invalid")
+	assert.Equal(t, []string{"validate", "veto"}, order)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, 1, skipped)
+	assert.Zero(t, evsw.Report().CallbackErrors)
+
+	order = nil
+	evsw.FireEvent(ctx, "event", "valid")
+	assert.Equal(t, []string{"validate", "veto", "apply"}, order)
+
+	// parallel fires have no order to stop, and do not report it as an error
+	require.NoError(t, evsw.FireEventParallel(ctx, "event", "invalid"))
+}
+
+func TestAddListenerNilCallback(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+
+	require.ErrorIs(t, evsw.AddListenerForEvent("listener", "event", nil), ErrNilCallback)
+	require.ErrorIs(t, evsw.AddFailoverListener("listener", "event", nil), ErrNilCallback)
+	require.ErrorIs(t, evsw.AddBatchingListener("listener", "event", time.Second, 1, nil), ErrNilCallback)
+	_, err := evsw.AddWeakListener(new(int), "event", nil)
+	require.ErrorIs(t, err, ErrNilCallback)
+
+	// nothing was registered
+	assert.Empty(t, evsw.EventNames())
+	assert.Empty(t, evsw.(*eventSwitch).listeners)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return nil }))
+	require.ErrorIs(t, evsw.ReplaceListenerCallback("listener", "event", nil), ErrNilCallback)
+}
+
+func TestAddListenerForEventBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	received := make(chan EventData, 1)
+	cb := func(_ context.Context, data EventData) error {
+		received <- data
+		return nil
+	}
+
+	// ctx done before Start: the listener is registered nonetheless
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer waitCancel()
+	require.ErrorIs(t, evsw.AddListenerForEventBlocking(waitCtx, "early", "event", cb),
+		context.DeadlineExceeded)
+	assert.Equal(t, []string{"early"}, evsw.Listeners("event"))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- evsw.AddListenerForEventBlocking(ctx, "listener", "other", cb)
+	}()
+	select {
+	case err := <-errCh:
+		t.Fatalf("returned before Start: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not return once started")
+	}
+
+	evsw.FireEvent(ctx, "other", "data")
+	assert.Equal(t, "data", <-received)
+
+	// already started: returns immediately
+	require.NoError(t, evsw.AddListenerForEventBlocking(ctx, "late", "event", cb))
+	require.ErrorIs(t, evsw.AddListenerForEventBlocking(ctx, "late", "event", nil), ErrNilCallback)
+}
+
+func TestStartTwice(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	require.ErrorIs(t, evsw.Start(ctx), service.ErrAlreadyStarted)
+	assert.True(t, evsw.IsRunning())
+
+	require.NoError(t, evsw.Stop())
+	// Wait is safe to call more than once
+	evsw.Wait()
+	evsw.Wait()
+	require.ErrorIs(t, evsw.Start(ctx), service.ErrAlreadyStarted)
+}
+
+func TestStartConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithLifecycleEvents())
+
+	var started int32
+	require.NoError(t, evsw.AddListenerForEvent("listener", SwitchStarted,
+		func(context.Context, EventData) error {
+			atomic.AddInt32(&started, 1)
+			return nil
+		}))
+
+	const n = 50
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- evsw.Start(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, service.ErrAlreadyStarted)
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.True(t, evsw.IsRunning())
+
+	require.NoError(t, evsw.Stop())
+	evsw.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&started))
+}
+
+func TestDoneAndContext(t *testing.T) {
+	type ctxKey struct{}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	preStart := evsw.Context()
+	require.NoError(t, evsw.Start(ctx))
+
+	runCtx := evsw.Context()
+	assert.Equal(t, "value", runCtx.Value(ctxKey{}))
+	select {
+	case <-evsw.Done():
+		t.Fatal("switch should be running")
+	case <-runCtx.Done():
+		t.Fatal("context should not be cancelled while running")
+	default:
+	}
+
+	require.NoError(t, evsw.Stop())
+	<-evsw.Done()
+	<-runCtx.Done()
+	<-preStart.Done()
+	assert.ErrorIs(t, runCtx.Err(), context.Canceled)
+}
+
+func TestDoneOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	cancel()
+	select {
+	case <-evsw.Done():
+	case <-time.After(time.Second):
+		t.Fatal("switch should stop when its start context is cancelled")
+	}
+	evsw.Wait()
+}
+
+func TestReplaceListenerCallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	noop := func(context.Context, EventData) error { return nil }
+	require.ErrorIs(t, evsw.ReplaceListenerCallback("listener", "event", noop),
+		ErrListenerNotSubscribed{listenerID: "listener", event: "event"})
+
+	var oldCount, newCount int
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			oldCount++
+			return nil
+		}))
+	evsw.FireEvent(ctx, "event", true)
+	require.NoError(t, evsw.ReplaceListenerCallback("listener", "event",
+		func(context.Context, EventData) error {
+			newCount++
+			return nil
+		}))
+	evsw.FireEvent(ctx, "event", true)
+	assert.Equal(t, 1, oldCount)
+	assert.Equal(t, 1, newCount)
+
+	// replacing does not subscribe the listener to other events
+	require.Error(t, evsw.ReplaceListenerCallback("listener", "other", noop))
+}
+
+// TestReplaceListenerCallbackConcurrency fires continuously while swapping
+// callbacks and checks that every fire is delivered exactly once.
+func TestReplaceListenerCallbackConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var delivered uint64
+	counter := func(context.Context, EventData) error {
+		atomic.AddUint64(&delivered, 1)
+		return nil
+	}
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event", counter))
+
+	const fires = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < fires; i++ {
+			evsw.FireEvent(ctx, "event", i)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.EqualValues(t, fires, atomic.LoadUint64(&delivered))
+			return
+		default:
+			require.NoError(t, evsw.ReplaceListenerCallback("listener", "event",
+				func(ctx context.Context, data EventData) error {
+					return counter(ctx, data)
+				}))
+		}
+	}
+}
+
+func TestRemoveListenersForEventPrefix(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := map[string]int{}
+	for _, sub := range []struct{ listenerID, event string }{
+		{"listener1", "consensus/vote"},
+		{"listener2", "consensus/vote"},
+		{"listener1", "consensus/proposal"},
+		{"listener1", "mempool/tx"},
+	} {
+		event := sub.event
+		require.NoError(t, evsw.AddListenerForEvent(sub.listenerID, event,
+			func(context.Context, EventData) error {
+				received[event]++
+				return nil
+			}))
+	}
+	ch, err := evsw.SubscribeChan("listener3", "consensus/vote", ChanOptions{BufferSize: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, evsw.RemoveListenersForEventPrefix("consensus/"))
+	assert.Zero(t, evsw.RemoveListenersForEventPrefix("consensus/"))
+	_, ok := <-ch
+	assert.False(t, ok, "channel subscription should be closed")
+
+	evsw.FireEvent(ctx, "consensus/vote", nil)
+	evsw.FireEvent(ctx, "consensus/proposal", nil)
+	evsw.FireEvent(ctx, "mempool/tx", nil)
+	assert.Equal(t, map[string]int{"mempool/tx": 1}, received)
+
+	// listeners can subscribe again afterwards
+	require.NoError(t, evsw.AddListenerForEvent("listener1", "consensus/vote",
+		func(context.Context, EventData) error {
+			received["consensus/vote"]++
+			return nil
+		}))
+	evsw.FireEvent(ctx, "consensus/vote", nil)
+	assert.Equal(t, 1, received["consensus/vote"])
+}
+
+func TestRemoveListenersForEventPrefixConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	noop := func(context.Context, EventData) error { return nil }
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			evsw.FireEvent(ctx, fmt.Sprintf("prefix/event%d", i%10), i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, evsw.AddListenerForEvent("listener", fmt.Sprintf("prefix/event%d", i%10), noop))
+		evsw.RemoveListenersForEventPrefix("prefix/")
+	}
+	<-done
+}
+
+// TestMutateFromCallback checks that every mutation method can be called from
+// within a callback without deadlocking, and that the changes take effect
+// from the next fire.
+func TestMutateFromCallback(t *testing.T) {
+	noop := func(context.Context, EventData) error { return nil }
+
+	testCases := map[string]func(evsw EventSwitch) error{
+		"add": func(evsw EventSwitch) error {
+			return evsw.AddListenerForEvent("other", "event", noop)
+		},
+		"add self to other event": func(evsw EventSwitch) error {
+			return evsw.AddListenerForEvent("listener", "other-event", noop)
+		},
+		"remove": func(evsw EventSwitch) error {
+			evsw.RemoveListener("listener")
+			return nil
+		},
+		"remove for event": func(evsw EventSwitch) error {
+			evsw.RemoveListenerForEvent("event", "listener")
+			return nil
+		},
+		"remove for prefix": func(evsw EventSwitch) error {
+			evsw.RemoveListenersForEventPrefix("ev")
+			return nil
+		},
+		"replace": func(evsw EventSwitch) error {
+			return evsw.ReplaceListenerCallback("listener", "event", noop)
+		},
+		"subscribe chan": func(evsw EventSwitch) error {
+			_, err := evsw.SubscribeChan("chan", "event", ChanOptions{BufferSize: 1})
+			return err
+		},
+		"fire": func(evsw EventSwitch) error {
+			evsw.FireEvent(context.Background(), "other-event", nil)
+			return nil
+		},
+	}
+
+	for name, mutate := range testCases {
+		mutate := mutate
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			evsw := NewEventSwitch(log.TestingLogger())
+			require.NoError(t, evsw.Start(ctx))
+			t.Cleanup(evsw.Wait)
+
+			calls := 0
+			require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+				func(context.Context, EventData) error {
+					calls++
+					return mutate(evsw)
+				}))
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				evsw.FireEvent(ctx, "event", nil)
+				evsw.FireEvent(ctx, "event", nil)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("mutating the switch from a callback deadlocked")
+			}
+			assert.GreaterOrEqual(t, calls, 1)
+		})
+	}
+}
+
+type clonableData struct {
+	values []int
+}
+
+func (d *clonableData) Clone() EventData {
+	return &clonableData{values: append([]int(nil), d.values...)}
+}
+
+func TestClonePerListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, clone := range []bool{false, true} {
+		var opts []Option
+		if clone {
+			opts = append(opts, WithClonePerListener())
+		}
+		evsw := NewEventSwitch(log.TestingLogger(), opts...)
+		require.NoError(t, evsw.Start(ctx))
+
+		// every listener mutates what it receives
+		var received []*clonableData
+		for _, listenerID := range []string{"listener1", "listener2"} {
+			require.NoError(t, evsw.AddListenerForEvent(listenerID, "event",
+				func(_ context.Context, data EventData) error {
+					d := data.(*clonableData)
+					d.values[0]++
+					received = append(received, d)
+					return nil
+				}))
+		}
+
+		data := &clonableData{values: []int{0}}
+		evsw.FireEvent(ctx, "event", data)
+		require.Len(t, received, 2)
+		if clone {
+			assert.Equal(t, 0, data.values[0])
+			assert.Equal(t, 1, received[0].values[0])
+			assert.Equal(t, 1, received[1].values[0])
+		} else {
+			assert.Equal(t, 2, data.values[0])
+			assert.Same(t, data, received[0])
+		}
+		require.NoError(t, evsw.Stop())
+	}
+}
+
 //------------------------------------------------------------------------------
 // Helper functions
 
@@ -549,3 +1186,57 @@ func fireEvents(ctx context.Context, evsw Fireable, event string, doneChan chan
 	case doneChan <- sentSum:
 	}
 }
+
+func TestFireEventSingleListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	calls := 0
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			calls++
+			return nil
+		}))
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[int]uint64{1: 1}, evsw.Stats().FanOut)
+
+	// the fast path gives up on done contexts too
+	doneCtx, doneCancel := context.WithCancel(ctx)
+	doneCancel()
+	evsw.FireEvent(doneCtx, "event", nil)
+	assert.Equal(t, 1, calls)
+}
+
+// BenchmarkFireEvent measures fires of events with a single listener, which
+// take a fast path, and with several listeners.
+func BenchmarkFireEvent(b *testing.B) {
+	for _, bm := range []struct {
+		name      string
+		listeners int
+	}{
+		{"single", 1},
+		{"multiple", 2},
+	} {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			ctx := context.Background()
+			evsw := NewEventSwitch(log.NewNopLogger())
+			for i := 0; i < bm.listeners; i++ {
+				require.NoError(b, evsw.AddListenerForEvent(fmt.Sprint("listener", i), "event",
+					func(context.Context, EventData) error { return nil }))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				evsw.FireEvent(ctx, "event", nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package eventstest provides helpers for testing code that uses an
+// events.EventSwitch.
+package eventstest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/events"
+)
+
+// nextID numbers the listeners registered by this package, so that
+// concurrent helpers never share a listener ID.
+var nextID uint64
+
+func listenerID() string {
+	return fmt.Sprintf("eventstest#%d", atomic.AddUint64(&nextID, 1))
+}
+
+// WaitForEvent blocks until event is fired on evsw and returns the data it
+// was fired with. It fails the test if the event is not fired within
+// timeout. The listener it registers is removed before it returns.
+func WaitForEvent(t testing.TB, evsw events.EventSwitch, event string, timeout time.Duration) events.EventData {
+	t.Helper()
+	return waitForEvent(t, evsw, event, nil, timeout)
+}
+
+// WaitForEventWhere is like WaitForEvent, but waits for the first fire of
+// event whose data satisfies pred, e.g. the new block at a given height.
+// pred is called from the fires of event and must not block.
+func WaitForEventWhere(
+	t testing.TB,
+	evsw events.EventSwitch,
+	event string,
+	pred func(events.EventData) bool,
+	timeout time.Duration,
+) events.EventData {
+	t.Helper()
+	return waitForEvent(t, evsw, event, pred, timeout)
+}
+
+// waitForEvent waits for the first fire of event whose data satisfies pred,
+// or for any fire if pred is nil.
+func waitForEvent(
+	t testing.TB,
+	evsw events.EventSwitch,
+	event string,
+	pred func(events.EventData) bool,
+	timeout time.Duration,
+) events.EventData {
+	t.Helper()
+
+	id := listenerID()
+	fired := make(chan events.EventData, 1)
+	if err := evsw.AddListenerForEvent(id, event, func(_ context.Context, data events.EventData) error {
+		if pred != nil && !pred(data) {
+			return nil
+		}
+		select {
+		case fired <- data:
+		default:
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribing to %s: %v", event, err)
+		return nil
+	}
+	defer evsw.RemoveListenerForEvent(event, id)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case data := <-fired:
+		return data
+	case <-timer.C:
+		if pred != nil {
+			t.Fatalf("timed out after %v waiting for %s matching the predicate", timeout, event)
+		} else {
+			t.Fatalf("timed out after %v waiting for %s", timeout, event)
+		}
+		return nil
+	}
+}
+
+// leakTimeout bounds how long AssertNoLeaks waits for goroutines to exit.
+var leakTimeout = 5 * time.Second
+
+// AssertNoLeaks runs fn, which is expected to start and stop one or more
+// switches and wait for them, and fails the test if more goroutines are
+// running afterwards than before. Goroutines started by fn are given a
+// short grace period to exit, since Wait may return before the background
+// goroutines of a switch have observed that it stopped.
+//
+// The check counts every goroutine of the process, so tests using it must
+// not run in parallel with other tests.
+func AssertNoLeaks(t testing.TB, fn func()) {
+	t.Helper()
+
+	before := runtime.NumGoroutine()
+	fn()
+
+	deadline := time.Now().Add(leakTimeout)
+	for {
+		after := runtime.NumGoroutine()
+		if after <= before {
+			return
+		}
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			buf = buf[:runtime.Stack(buf, true)]
+			t.Fatalf("%d goroutines leaked (%d before, %d after):\n%s", after-before, before, after, buf)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,122 @@
+package eventstest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/events"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// fakeT records the failures of a test instead of stopping it.
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func newSwitch(t *testing.T) events.EventSwitch {
+	evsw := events.NewEventSwitch(log.TestingLogger())
+	t.Cleanup(evsw.Wait)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, evsw.Start(ctx))
+	return evsw
+}
+
+func TestWaitForEvent(t *testing.T) {
+	evsw := newSwitch(t)
+
+	go func() {
+		for len(evsw.Listeners("event")) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		evsw.FireEvent(context.Background(), "event", "data")
+	}()
+
+	assert.Equal(t, "data", WaitForEvent(t, evsw, "event", 5*time.Second))
+	assert.Empty(t, evsw.Listeners("event"))
+}
+
+func TestWaitForEventTimeout(t *testing.T) {
+	evsw := newSwitch(t)
+
+	ft := &fakeT{TB: t}
+	assert.Nil(t, WaitForEvent(ft, evsw, "event", 10*time.Millisecond))
+	require.Len(t, ft.failures, 1)
+	assert.Contains(t, ft.failures[0], "timed out")
+	assert.Empty(t, evsw.Listeners("event"))
+}
+
+func TestWaitForEventWhere(t *testing.T) {
+	evsw := newSwitch(t)
+
+	go func() {
+		for len(evsw.Listeners("height")) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for h := 1; h <= 5; h++ {
+			evsw.FireEvent(context.Background(), "height", h)
+		}
+	}()
+
+	atLeast3 := func(data events.EventData) bool { return data.(int) >= 3 }
+	assert.Equal(t, 3, WaitForEventWhere(t, evsw, "height", atLeast3, 5*time.Second))
+	assert.Empty(t, evsw.Listeners("height"))
+}
+
+func TestWaitForEventWhereTimeout(t *testing.T) {
+	evsw := newSwitch(t)
+
+	go func() {
+		for len(evsw.Listeners("height")) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		evsw.FireEvent(context.Background(), "height", 1)
+	}()
+
+	ft := &fakeT{TB: t}
+	never := func(events.EventData) bool { return false }
+	assert.Nil(t, WaitForEventWhere(ft, evsw, "height", never, 50*time.Millisecond))
+	require.Len(t, ft.failures, 1)
+	assert.Contains(t, ft.failures[0], "timed out after 50ms waiting for height matching the predicate")
+	assert.Empty(t, evsw.Listeners("height"))
+}
+
+func TestAssertNoLeaks(t *testing.T) {
+	AssertNoLeaks(t, func() {
+		evsw := events.NewEventSwitch(log.TestingLogger())
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, evsw.Start(ctx))
+		cancel()
+		evsw.Wait()
+	})
+}
+
+func TestAssertNoLeaksDetectsLeak(t *testing.T) {
+	defer func(timeout time.Duration) { leakTimeout = timeout }(leakTimeout)
+	leakTimeout = 50 * time.Millisecond
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	ft := &fakeT{TB: t}
+	start := time.Now()
+	AssertNoLeaks(ft, func() {
+		go func() { <-stop }()
+	})
+	require.Len(t, ft.failures, 1)
+	assert.Contains(t, ft.failures[0], "1 goroutines leaked")
+	assert.GreaterOrEqual(t, time.Since(start), leakTimeout)
+}
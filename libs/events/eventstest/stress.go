@@ -0,0 +1,159 @@
+package eventstest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/events"
+)
+
+// StressConfig configures Stress. Zero fields take their defaults.
+type StressConfig struct {
+	// Duration is how long the load runs, one second by default.
+	Duration time.Duration
+	// Events is the number of distinct events fired, 4 by default.
+	Events int
+	// Firers is the number of goroutines firing, 4 by default.
+	Firers int
+	// Churners is the number of goroutines adding and removing listeners,
+	// 4 by default, and Listeners the number of listener IDs they share, 8
+	// by default.
+	Churners  int
+	Listeners int
+	// Seed seeds the random choices of the load; zero picks a seed from the
+	// current time. The seed is logged so that a failing run can be
+	// repeated.
+	Seed int64
+}
+
+func (cfg StressConfig) withDefaults() StressConfig {
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Second
+	}
+	if cfg.Events <= 0 {
+		cfg.Events = 4
+	}
+	if cfg.Firers <= 0 {
+		cfg.Firers = 4
+	}
+	if cfg.Churners <= 0 {
+		cfg.Churners = 4
+	}
+	if cfg.Listeners <= 0 {
+		cfg.Listeners = 8
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	return cfg
+}
+
+// Stress runs randomized load against evsw, which must be started, for the
+// configured duration: some goroutines fire events with FireEvent while
+// others keep adding and removing listeners of them. Each event also has a
+// tracked listener, subscribed for the whole run, which must receive every
+// fire; the switch must therefore deliver every fire of the events
+// synchronously, e.g. without a fire interceptor dropping them. Stress
+// fails the test if a tracked listener missed a fire or if goroutines are
+// left running afterwards, see AssertNoLeaks. Run under the race detector,
+// it also exercises the switch, and whatever is wired to it, for data races.
+//
+// The events and listeners are named after the run, so that they do not
+// collide with those of the code under test. All of them are removed before
+// Stress returns.
+func Stress(t testing.TB, evsw events.EventSwitch, cfg StressConfig) {
+	t.Helper()
+
+	cfg = cfg.withDefaults()
+	t.Logf("stressing the event switch for %v with seed %d", cfg.Duration, cfg.Seed)
+
+	run := listenerID()
+	eventNames := make([]string, cfg.Events)
+	for i := range eventNames {
+		eventNames[i] = fmt.Sprintf("%s/event%d", run, i)
+	}
+	listenerIDs := make([]string, cfg.Listeners)
+	for i := range listenerIDs {
+		listenerIDs[i] = fmt.Sprintf("%s/listener%d", run, i)
+	}
+	tracker := run + "/tracker"
+	defer func() {
+		evsw.RemoveListener(tracker)
+		for _, id := range listenerIDs {
+			evsw.RemoveListener(id)
+		}
+	}()
+
+	fired := make([]uint64, cfg.Events)
+	received := make([]uint64, cfg.Events)
+	for i, event := range eventNames {
+		i := i
+		if err := evsw.AddListenerForEvent(tracker, event, func(context.Context, events.EventData) error {
+			atomic.AddUint64(&received[i], 1)
+			return nil
+		}); err != nil {
+			t.Fatalf("subscribing to %s: %v", event, err)
+			return
+		}
+	}
+
+	AssertNoLeaks(t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Duration)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		for i := 0; i < cfg.Firers; i++ {
+			rng := rand.New(rand.NewSource(cfg.Seed + int64(i)))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					n := rng.Intn(cfg.Events)
+					atomic.AddUint64(&fired[n], 1)
+					_ = evsw.FireEvent(context.Background(), eventNames[n], n)
+				}
+			}()
+		}
+		for i := 0; i < cfg.Churners; i++ {
+			rng := rand.New(rand.NewSource(cfg.Seed + int64(cfg.Firers+i)))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					churn(evsw, rng, eventNames, listenerIDs)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	for i, event := range eventNames {
+		if f, r := atomic.LoadUint64(&fired[i]), atomic.LoadUint64(&received[i]); f != r {
+			t.Fatalf("the tracked listener of %s received %d of its %d fires (seed %d)", event, r, f, cfg.Seed)
+			return
+		}
+	}
+}
+
+// churn makes one random change to the listeners of events.
+func churn(evsw events.EventSwitch, rng *rand.Rand, eventNames, listenerIDs []string) {
+	id := listenerIDs[rng.Intn(len(listenerIDs))]
+	event := eventNames[rng.Intn(len(eventNames))]
+	switch rng.Intn(4) {
+	case 0, 1:
+		// Errors, e.g. because the listener is already subscribed, are part
+		// of the load.
+		_ = evsw.AddListenerForEvent(id, event, func(context.Context, events.EventData) error {
+			return nil
+		})
+	case 2:
+		evsw.RemoveListenerForEvent(event, id)
+	default:
+		evsw.RemoveListener(id)
+	}
+}
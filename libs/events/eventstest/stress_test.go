@@ -0,0 +1,42 @@
+package eventstest
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/events"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestStress(t *testing.T) {
+	evsw := newSwitch(t)
+	Stress(t, evsw, StressConfig{Duration: 200 * time.Millisecond})
+}
+
+func TestStressDetectsLostEvents(t *testing.T) {
+	// drop every hundredth fire of the stress events
+	var n uint64
+	evsw := events.NewEventSwitch(log.TestingLogger(), events.WithFireInterceptor(
+		func(event string, _ events.EventData) (bool, time.Duration) {
+			if !strings.HasPrefix(event, "eventstest#") {
+				return true, 0
+			}
+			return atomic.AddUint64(&n, 1)%100 != 0, 0
+		}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	ft := &fakeT{TB: t}
+	Stress(ft, evsw, StressConfig{Duration: 100 * time.Millisecond, Events: 1, Seed: 1})
+	require.Len(t, ft.failures, 1)
+	assert.Contains(t, ft.failures[0], "the tracked listener of")
+	assert.Contains(t, ft.failures[0], "(seed 1)")
+}
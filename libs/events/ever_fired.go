@@ -0,0 +1,30 @@
+package events
+
+import "sync"
+
+// firedEvents is the set of events fired at least once, bounded like the
+// cached values, by WithMaxCachedEvents.
+type firedEvents struct {
+	mtx    sync.Mutex
+	events eventLRU
+}
+
+// record adds event to the set.
+func (f *firedEvents) record(event string) {
+	f.mtx.Lock()
+	f.events.set(event, true)
+	f.mtx.Unlock()
+}
+
+func (f *firedEvents) has(event string) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.events.get(event) != nil
+}
+
+func (evsw *eventSwitch) EverFired(event string) bool {
+	if evsw.fired == nil {
+		return false
+	}
+	return evsw.fired.has(event)
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestEverFired(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithEverFired())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return nil }))
+	assert.False(t, evsw.EverFired("event"))
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.True(t, evsw.EverFired("event"))
+
+	// fires reaching no listener count too
+	evsw.DisableEvent("disabled")
+	evsw.FireEvent(ctx, "disabled", nil)
+	evsw.FireEvent(ctx, "unheard", nil)
+	assert.True(t, evsw.EverFired("disabled"))
+	assert.True(t, evsw.EverFired("unheard"))
+	assert.False(t, evsw.EverFired("never"))
+}
+
+func TestEverFiredOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// fires are not recorded by default
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.False(t, evsw.EverFired("event"))
+
+	// the recorded events are bounded by WithMaxCachedEvents
+	bounded := NewEventSwitch(log.TestingLogger(), WithEverFired(), WithMaxCachedEvents(2))
+	require.NoError(t, bounded.Start(ctx))
+	t.Cleanup(bounded.Wait)
+	for _, event := range []string{"a", "b", "a", "c"} {
+		bounded.FireEvent(ctx, event, nil)
+	}
+	assert.True(t, bounded.EverFired("a"))
+	assert.False(t, bounded.EverFired("b"))
+	assert.True(t, bounded.EverFired("c"))
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrFailoverTimeout is the error recorded for a failover group member that
+// did not return within the timeout set with WithFailoverTimeout.
+var ErrFailoverTimeout = errors.New("failover member timed out")
+
+// failoverGroup delivers each fire to the first of its members that handles
+// it successfully.
+type failoverGroup struct {
+	// timeout bounds each member; zero leaves them unbounded.
+	timeout time.Duration
+	clock   Clock
+
+	mtx     sync.RWMutex
+	members []EventCallback
+}
+
+func (g *failoverGroup) add(cb EventCallback) {
+	g.mtx.Lock()
+	g.members = append(g.members, cb)
+	g.mtx.Unlock()
+}
+
+// fire tries the members in registration order until one returns nil or
+// ErrStopPropagation, which is passed through. It returns the error of the
+// last member if none succeeds.
+func (g *failoverGroup) fire(ctx context.Context, data EventData) error {
+	g.mtx.RLock()
+	members := g.members
+	g.mtx.RUnlock()
+
+	var err error
+	for _, cb := range members {
+		err = g.call(ctx, cb, data)
+		if err == nil || errors.Is(err, ErrStopPropagation) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// call runs a member, giving up on it once the timeout of the group has
+// elapsed. A member given up on has its context cancelled and keeps running
+// in the background, where its outcome is ignored.
+func (g *failoverGroup) call(ctx context.Context, cb EventCallback, data EventData) error {
+	if g.timeout <= 0 {
+		return cb(ctx, data)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cb(ctx, data) }()
+
+	timer := g.clock.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C():
+		return fmt.Errorf("%w after %v", ErrFailoverTimeout, g.timeout)
+	}
+}
+
+func (evsw *eventSwitch) AddFailoverListener(groupID, event string, cb EventCallback) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+	key := subKey{listenerID: groupID, event: event}
+
+	// The group is looked up and created under a single lock, so that
+	// concurrent first members join the same group.
+	evsw.failoverMtx.Lock()
+	defer evsw.failoverMtx.Unlock()
+
+	evsw.mtx.RLock()
+	g := evsw.failover[key]
+	evsw.mtx.RUnlock()
+	if g != nil {
+		g.add(cb)
+		return nil
+	}
+
+	g = &failoverGroup{timeout: evsw.failoverTimeout, clock: evsw.clock, members: []EventCallback{cb}}
+	if err := evsw.addListener(groupID, event, g.fire, subState{}); err != nil {
+		return err
+	}
+	evsw.mtx.Lock()
+	evsw.failover[key] = g
+	evsw.mtx.Unlock()
+	return nil
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddFailoverListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var calls []string
+	primaryFails := true
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error {
+			calls = append(calls, "primary")
+			if primaryFails {
+				return errors.New("primary failed")
+			}
+			return nil
+		}))
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error {
+			calls = append(calls, "standby")
+			return nil
+		}))
+	assert.Equal(t, []string{"group"}, evsw.Listeners("event"))
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"primary", "standby"}, calls)
+
+	calls = nil
+	primaryFails = false
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"primary"}, calls)
+
+	// removing the group removes all its members
+	evsw.RemoveListener("group")
+	calls = nil
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Empty(t, calls)
+}
+
+func TestAddFailoverListenerAllFail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errLast := errors.New("standby failed")
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error { return errors.New("primary failed") }))
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error { return errLast }))
+
+	require.ErrorIs(t, evsw.FireEventParallel(ctx, "event", nil), errLast)
+
+	// a group recreated after removal starts with no members
+	evsw.RemoveListenerForEvent("event", "group")
+	called := false
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error {
+			called = true
+			return nil
+		}))
+	require.NoError(t, evsw.FireEventParallel(ctx, "event", nil))
+	assert.True(t, called)
+}
+
+func TestAddFailoverListenerTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const timeout = time.Second
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithFailoverTimeout(timeout))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	primaryCancelled := make(chan struct{})
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(ctx context.Context, _ EventData) error {
+			<-ctx.Done()
+			close(primaryCancelled)
+			return nil
+		}))
+	standby := make(chan EventData, 1)
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(_ context.Context, data EventData) error {
+			standby <- data
+			return nil
+		}))
+
+	fired := make(chan struct{})
+	go func() {
+		defer close(fired)
+		evsw.FireEvent(ctx, "event", "data")
+	}()
+
+	// the lag monitor holds a timer too
+	clock.waitForTimers(t, 2)
+	clock.Advance(timeout)
+	<-fired
+	assert.Equal(t, "data", <-standby)
+	<-primaryCancelled
+}
+
+func TestAddFailoverListenerStopPropagation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var calls []string
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error {
+			calls = append(calls, "primary")
+			return ErrStopPropagation
+		}))
+	require.NoError(t, evsw.AddFailoverListener("group", "event",
+		func(context.Context, EventData) error {
+			calls = append(calls, "standby")
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("after", "event",
+		func(context.Context, EventData) error {
+			calls = append(calls, "after")
+			return nil
+		}))
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"primary"}, calls,
+		"a stopped propagation is not a failure and stops the fire")
+}
+
+func TestAddFailoverListenerConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	const members = 16
+	var (
+		calls int64
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < members; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, evsw.AddFailoverListener("group", "event",
+				func(context.Context, EventData) error {
+					atomic.AddInt64(&calls, 1)
+					return fmt.Errorf("member %d failed", i)
+				}))
+		}()
+	}
+	wg.Wait()
+
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, int64(members), atomic.LoadInt64(&calls), "every member joined the group")
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FilePersister is a Persister and Replayer appending the fires to a file,
+// one NDJSONCodec record per line, along with their acknowledgements, and
+// syncing the file after each of them. The data is recorded unredacted,
+// since it is replayed to the listeners. The file is never compacted: it
+// grows with every fire persisted.
+type FilePersister struct {
+	mtx  sync.Mutex
+	file *os.File
+}
+
+var (
+	_ Persister = (*FilePersister)(nil)
+	_ Replayer  = (*FilePersister)(nil)
+)
+
+// fileAck is the line acknowledging the fire numbered Ack.
+type fileAck struct {
+	Ack uint64 `json:"ack"`
+}
+
+// NewFilePersister opens, or creates, the file at path for a FilePersister.
+// The caller must close it once the switch has stopped.
+func NewFilePersister(path string) (*FilePersister, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FilePersister{file: file}, nil
+}
+
+// Persist implements Persister.
+func (fp *FilePersister) Persist(seq uint64, event string, data EventData, at time.Time) error {
+	b, err := NDJSONCodec{}.Encode(NamedEvent{Event: event, Data: data, Seq: seq, Time: at})
+	if err != nil {
+		return err
+	}
+	return fp.append(b)
+}
+
+// Ack implements Replayer.
+func (fp *FilePersister) Ack(seq uint64) error {
+	b, err := json.Marshal(fileAck{Ack: seq})
+	if err != nil {
+		return err
+	}
+	return fp.append(append(b, '\n'))
+}
+
+// append writes line at the end of the file and syncs it.
+func (fp *FilePersister) append(line []byte) error {
+	fp.mtx.Lock()
+	defer fp.mtx.Unlock()
+	if _, err := fp.file.Write(line); err != nil {
+		return err
+	}
+	return fp.file.Sync()
+}
+
+// Recover implements Persister. A last line left incomplete by a crash is
+// discarded.
+func (fp *FilePersister) Recover() (uint64, error) {
+	var seq uint64
+	err := fp.scan(func(ne NamedEvent) { seq = ne.Seq }, func(uint64) {})
+	if err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// Unacked implements Replayer. A last line left incomplete by a crash is
+// discarded.
+func (fp *FilePersister) Unacked() ([]NamedEvent, error) {
+	var (
+		persisted []NamedEvent
+		acked     = make(map[uint64]struct{})
+	)
+	err := fp.scan(
+		func(ne NamedEvent) { persisted = append(persisted, ne) },
+		func(seq uint64) { acked[seq] = struct{}{} },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	unacked := persisted[:0]
+	for _, ne := range persisted {
+		if _, ok := acked[ne.Seq]; !ok {
+			unacked = append(unacked, ne)
+		}
+	}
+	return unacked, nil
+}
+
+// scan reads the file from the start, calling onFire for each fire and
+// onAck for each acknowledgement, in order. A last line left incomplete by a
+// crash is truncated away, since appends go to the end of the file and it
+// must go before the next line is written.
+func (fp *FilePersister) scan(onFire func(NamedEvent), onAck func(seq uint64)) error {
+	fp.mtx.Lock()
+	defer fp.mtx.Unlock()
+
+	if _, err := fp.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var (
+		r      = bufio.NewReader(fp.file)
+		offset int64
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return fp.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var ack fileAck
+			if err := json.Unmarshal(line, &ack); err == nil && ack.Ack != 0 {
+				onAck(ack.Ack)
+			} else {
+				ne, err := NDJSONCodec{}.Decode(line)
+				if err != nil {
+					return fmt.Errorf("decoding the record at offset %d: %w", offset, err)
+				}
+				onFire(ne)
+			}
+		}
+		offset += int64(len(line))
+	}
+}
+
+// Close closes the file.
+func (fp *FilePersister) Close() error {
+	return fp.file.Close()
+}
@@ -0,0 +1,201 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFilePersister(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	fp, err := NewFilePersister(path)
+	require.NoError(t, err)
+	seq, err := fp.Recover()
+	require.NoError(t, err)
+	assert.Zero(t, seq)
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		require.NoError(t, fp.Persist(seq, "committed", seq, time.Unix(int64(seq), 0)))
+	}
+	require.NoError(t, fp.Close())
+
+	// the records carry the fire time they were given
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	first, err := NDJSONCodec{}.Decode(b[:bytes.IndexByte(b, '\n')])
+	require.NoError(t, err)
+	assert.True(t, first.Time.Equal(time.Unix(1, 0)), first.Time)
+
+	fp, err = NewFilePersister(path)
+	require.NoError(t, err)
+	defer fp.Close()
+	seq, err = fp.Recover()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), seq)
+}
+
+func TestFilePersisterUnacked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	fp, err := NewFilePersister(path)
+	require.NoError(t, err)
+	for seq := uint64(1); seq <= 3; seq++ {
+		require.NoError(t, fp.Persist(seq, "committed", "block", time.Unix(int64(seq), 0)))
+	}
+	require.NoError(t, fp.Ack(1))
+	require.NoError(t, fp.Ack(3))
+	require.NoError(t, fp.Close())
+
+	fp, err = NewFilePersister(path)
+	require.NoError(t, err)
+	defer fp.Close()
+	seq, err := fp.Recover()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), seq)
+	unacked, err := fp.Unacked()
+	require.NoError(t, err)
+	require.Len(t, unacked, 1)
+	assert.Equal(t, "committed", unacked[0].Event)
+	assert.Equal(t, "block", unacked[0].Data)
+	assert.Equal(t, uint64(2), unacked[0].Seq)
+	assert.True(t, unacked[0].Time.Equal(time.Unix(2, 0)), unacked[0].Time)
+}
+
+func TestFilePersisterIncompleteRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	fp, err := NewFilePersister(path)
+	require.NoError(t, err)
+	require.NoError(t, fp.Persist(1, "committed", nil, time.Unix(1, 0)))
+	require.NoError(t, fp.Close())
+
+	// a crash in the middle of a write
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"event":"commi`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	fp, err = NewFilePersister(path)
+	require.NoError(t, err)
+	defer fp.Close()
+	seq, err := fp.Recover()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), seq)
+
+	require.NoError(t, fp.Persist(2, "committed", nil, time.Unix(2, 0)))
+	seq, err = fp.Recover()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), seq)
+}
+
+func TestFilePersisterCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("garbage\n"), 0o600))
+
+	fp, err := NewFilePersister(path)
+	require.NoError(t, err)
+	defer fp.Close()
+	_, err = fp.Recover()
+	assert.Error(t, err)
+}
+
+func TestFilePersisterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	run := func(fires int) []uint64 {
+		fp, err := NewFilePersister(path)
+		require.NoError(t, err)
+		defer fp.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		evsw := NewEventSwitch(log.TestingLogger(), WithPersister(fp))
+		require.NoError(t, evsw.Start(ctx))
+
+		var seqs []uint64
+		require.NoError(t, evsw.AddDurableListener("listener", "committed",
+			func(ctx context.Context, _ EventData) error {
+				seq, _ := SeqFromContext(ctx)
+				seqs = append(seqs, seq)
+				return nil
+			}))
+		for i := 0; i < fires; i++ {
+			evsw.FireEvent(ctx, "committed", i)
+		}
+
+		cancel()
+		evsw.Wait()
+		return seqs
+	}
+
+	assert.Equal(t, []uint64{1, 2}, run(2))
+	assert.Equal(t, []uint64{3, 4, 5}, run(3), "numbering resumes after a restart")
+}
+
+func TestFilePersisterReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	// processed is the state of the listener, which survives restarts
+	// along with the number of the last fire it processed.
+	var (
+		processed []EventData
+		last      uint64
+	)
+	run := func(fires []EventData, crashOn EventData) {
+		fp, err := NewFilePersister(path)
+		require.NoError(t, err)
+		defer fp.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		evsw := NewEventSwitch(log.TestingLogger(), WithPersister(fp))
+		require.NoError(t, evsw.AddDurableListener("listener", "committed",
+			func(ctx context.Context, data EventData) error {
+				seq, _ := SeqFromContext(ctx)
+				if seq <= last {
+					return nil
+				}
+				processed = append(processed, data)
+				last = seq
+				if data == crashOn {
+					// the switch goes down before acknowledging the fire
+					cancel()
+				}
+				return nil
+			}))
+		require.NoError(t, evsw.Start(ctx))
+		for _, data := range fires {
+			if ctx.Err() != nil {
+				break
+			}
+			evsw.FireEvent(ctx, "committed", data)
+		}
+
+		cancel()
+		evsw.Wait()
+	}
+
+	run([]EventData{"a", "b", "c"}, "b")
+	assert.Equal(t, []EventData{"a", "b"}, processed)
+	// b is replayed, and skipped by the listener, before d is fired
+	run([]EventData{"d"}, nil)
+	assert.Equal(t, []EventData{"a", "b", "d"}, processed)
+	assert.Equal(t, uint64(3), last)
+
+	fp, err := NewFilePersister(path)
+	require.NoError(t, err)
+	defer fp.Close()
+	unacked, err := fp.Unacked()
+	require.NoError(t, err)
+	assert.Empty(t, unacked)
+}
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func (evsw *eventSwitch) FireEventParallel(ctx context.Context, event string, data EventData) error {
+	if !evsw.fires.begin() {
+		return nil
+	}
+	defer evsw.fires.end()
+
+	pf, err := evsw.prepareFire(ctx, event, data)
+	if err != nil {
+		if errors.Is(err, errFireRejected) {
+			return nil
+		}
+		return err
+	}
+	defer evsw.finishFire(ctx, event, data, pf)
+
+	g, gctx := errgroup.WithContext(pf.ctx)
+	for _, lc := range pf.callbacks {
+		lc := lc
+		g.Go(func() error {
+			err := evsw.invoke(gctx, lc, pf.data)
+			if errors.Is(err, errEventDropped) || errors.Is(err, ErrStopPropagation) {
+				return nil
+			}
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// ErrNotDelivered is returned by FireEventAwait when the awaited listener
+// was skipped, e.g. because its channel subscription dropped the event, the
+// fire was rejected, say for a disabled event, or ctx was done before its
+// callback could run.
+var ErrNotDelivered = errors.New("event was not delivered to the listener")
+
+func (evsw *eventSwitch) FireEventAwait(ctx context.Context, event string, data EventData, listenerID string) error {
+	if !evsw.fires.begin() {
+		return ErrNotDelivered
+	}
+	defer evsw.fires.end()
+
+	pf, err := evsw.prepareFire(ctx, event, data)
+	if err != nil {
+		// A rejected fire collects no callbacks, so the subscription has to
+		// be looked up on its own.
+		switch {
+		case !evsw.isSubscribed(listenerID, event):
+			return ErrListenerNotSubscribed{listenerID: listenerID, event: event}
+		case errors.Is(err, errFireRejected):
+			return ErrNotDelivered
+		}
+		return err
+	}
+
+	var (
+		awaited listenerCallback
+		found   bool
+		others  = make([]listenerCallback, 0, len(pf.callbacks))
+	)
+	for _, lc := range pf.callbacks {
+		if lc.listenerID == listenerID && !found {
+			awaited, found = lc, true
+			continue
+		}
+		others = append(others, lc)
+	}
+
+	// The background delivery counts as a fire in progress of its own, so
+	// that Shutdown waits for it too. Like the deliveries of the worker
+	// pool, it outlives ctx, which the caller may cancel as soon as we
+	// return. A persisted fire is then acknowledged once both the awaited
+	// listener and the others received it.
+	if seq := pf.delivery.seq; len(others) > 0 {
+		pf.delivery.seq = 0
+		if evsw.fires.begin() {
+			bgCtx := pf.ctx
+			if !evsw.inheritContext {
+				bgCtx = detachContext(bgCtx, evsw.Context())
+			}
+			awaitedDone := make(chan bool, 1)
+			defer func() { awaitedDone <- pf.ctx.Err() == nil }()
+			go func() {
+				defer evsw.fires.end()
+				evsw.dispatch(bgCtx, others, pf.data)
+				if <-awaitedDone && bgCtx.Err() == nil && seq != 0 {
+					evsw.ack(seq)
+				}
+			}()
+		}
+	}
+	defer evsw.finishFire(ctx, event, data, pf)
+
+	if !found {
+		return ErrListenerNotSubscribed{listenerID: listenerID, event: event}
+	}
+	if ctx.Err() != nil {
+		return ErrNotDelivered
+	}
+	err = evsw.invoke(pf.ctx, awaited, pf.data)
+	switch {
+	case errors.Is(err, errEventDropped):
+		return ErrNotDelivered
+	case errors.Is(err, ErrStopPropagation):
+		return nil
+	}
+	return err
+}
+
+// ListenerErrors is returned by FireEventFirstSuccess when every listener
+// failed, with the errors they returned in the order they were invoked.
+type ListenerErrors []error
+
+func (errs ListenerErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return "all listeners failed: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (errs ListenerErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (evsw *eventSwitch) FireEventFirstSuccess(ctx context.Context, event string, data EventData) error {
+	if !evsw.fires.begin() {
+		return ErrNotDelivered
+	}
+	defer evsw.fires.end()
+
+	pf, err := evsw.prepareFire(ctx, event, data)
+	if err != nil {
+		if errors.Is(err, errFireRejected) {
+			return ErrNotDelivered
+		}
+		return err
+	}
+	defer evsw.finishFire(ctx, event, data, pf)
+
+	var errs ListenerErrors
+	for _, lc := range pf.callbacks {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		err := evsw.invoke(pf.ctx, lc, pf.data)
+		switch {
+		case err == nil, errors.Is(err, ErrStopPropagation):
+			return nil
+		case errors.Is(err, errEventDropped):
+			continue
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return ErrNotDelivered
+	}
+	return errs
+}
+
+func (evsw *eventSwitch) FireEventDetached(event string, data EventData) {
+	_ = evsw.FireEvent(evsw.Context(), event, data)
+}
+
+func (evsw *eventSwitch) FireFromChannel(ctx context.Context, event string, ch <-chan EventData) {
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			_ = evsw.FireEvent(ctx, event, data)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (evsw *eventSwitch) FireEventCounted(ctx context.Context, event string, data EventData) (delivered, skipped int) {
+	if !evsw.fires.begin() {
+		return 0, 0
+	}
+	defer evsw.fires.end()
+
+	pf, err := evsw.prepareFire(ctx, event, data)
+	if err != nil {
+		evsw.logTransformError(event, err)
+		return 0, 0
+	}
+	defer evsw.finishFire(ctx, event, data, pf)
+	return evsw.dispatch(pf.ctx, pf.callbacks, pf.data)
+}
@@ -0,0 +1,29 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// fireTimeKey is the context key of the time attached by FireEventAt.
+type fireTimeKey struct{}
+
+// FireTimeFromContext returns the logical time of the fire whose callback
+// received ctx, as passed to FireEventAt, if any.
+func FireTimeFromContext(ctx context.Context) (time.Time, bool) {
+	at, ok := ctx.Value(fireTimeKey{}).(time.Time)
+	return at, ok
+}
+
+func (evsw *eventSwitch) FireEventAt(ctx context.Context, event string, data EventData, at time.Time) error {
+	return evsw.FireEvent(context.WithValue(ctx, fireTimeKey{}, at), event, data)
+}
+
+// fireTime returns the time of a fire made with ctx: the time given to
+// FireEventAt, or else the current time.
+func (evsw *eventSwitch) fireTime(ctx context.Context) time.Time {
+	if at, ok := FireTimeFromContext(ctx); ok {
+		return at
+	}
+	return evsw.clock.Now()
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireEventAt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithCallerInfo())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var times []time.Time
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(ctx context.Context, _ EventData) error {
+			at, ok := FireTimeFromContext(ctx)
+			if ok {
+				times = append(times, at)
+			}
+			return nil
+		}))
+
+	past := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	evsw.FireEventAt(ctx, "event", nil, past)
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []time.Time{past}, times)
+
+	fires := evsw.Stats().RecentFires
+	require.Len(t, fires, 2)
+	assert.Equal(t, past, fires[0].Time)
+	assert.Equal(t, clock.Now(), fires[1].Time)
+}
@@ -0,0 +1,426 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireEventParallel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	// all listeners must be running at the same time to get past the barrier
+	const numListeners = 5
+	barrier := make(chan struct{})
+	arrived := make(chan struct{}, numListeners)
+	for i := 0; i < numListeners; i++ {
+		require.NoError(t, evsw.AddListenerForEvent(fmt.Sprintf("listener%d", i), "event",
+			func(ctx context.Context, _ EventData) error {
+				arrived <- struct{}{}
+				select {
+				case <-barrier:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}))
+	}
+	go func() {
+		for i := 0; i < numListeners; i++ {
+			<-arrived
+		}
+		close(barrier)
+	}()
+
+	require.NoError(t, evsw.FireEventParallel(ctx, "event", nil))
+	require.NoError(t, evsw.FireEventParallel(ctx, "nothing", nil))
+
+	// a dropped event is not an error
+	_, err := evsw.SubscribeChan("chan", "dropping", ChanOptions{OnFull: DropNewest})
+	require.NoError(t, err)
+	require.NoError(t, evsw.FireEventParallel(ctx, "dropping", nil))
+}
+
+func TestFireEventParallelError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errFailed := errors.New("failed")
+	require.NoError(t, evsw.AddListenerForEvent("failing", "event",
+		func(context.Context, EventData) error { return errFailed }))
+	require.NoError(t, evsw.AddListenerForEvent("blocking", "event",
+		func(ctx context.Context, _ EventData) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Second):
+				return errors.New("not cancelled")
+			}
+		}))
+
+	require.ErrorIs(t, evsw.FireEventParallel(ctx, "event", nil), errFailed)
+	assert.NoError(t, ctx.Err(), "the caller's context must not be cancelled")
+}
+
+func TestFireEventCounted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithFireInterceptor(
+		func(event string, _ EventData) (bool, time.Duration) {
+			return event != "intercepted", 0
+		}))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	delivered, skipped := evsw.FireEventCounted(ctx, "event", nil)
+	assert.Zero(t, delivered)
+	assert.Zero(t, skipped)
+
+	require.NoError(t, evsw.AddListenerForEvent("callback", "event",
+		func(context.Context, EventData) error { return nil }))
+	require.NoError(t, evsw.AddListenerForEvent("failing", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	_, err := evsw.SubscribeChan("chan", "event", ChanOptions{BufferSize: 1, OnFull: DropNewest})
+	require.NoError(t, err)
+
+	delivered, skipped = evsw.FireEventCounted(ctx, "event", 1)
+	assert.Equal(t, 3, delivered)
+	assert.Zero(t, skipped)
+
+	// the channel buffer is now full
+	delivered, skipped = evsw.FireEventCounted(ctx, "event", 2)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, 1, skipped)
+
+	require.NoError(t, evsw.AddListenerForEvent("callback", "intercepted",
+		func(context.Context, EventData) error { return nil }))
+	delivered, skipped = evsw.FireEventCounted(ctx, "intercepted", nil)
+	assert.Zero(t, delivered)
+	assert.Zero(t, skipped)
+
+	// drops are not callback errors
+	assert.Equal(t, uint64(2), evsw.Report().CallbackErrors)
+}
+
+func TestFireEventStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	fireCtx, fireCancel := context.WithCancel(ctx)
+	defer fireCancel()
+
+	// whichever listener runs first cancels the fire
+	calls := 0
+	for i := 0; i < 5; i++ {
+		require.NoError(t, evsw.AddListenerForEvent(fmt.Sprintf("listener%d", i), "event",
+			func(context.Context, EventData) error {
+				calls++
+				fireCancel()
+				return nil
+			}))
+	}
+
+	delivered, skipped := evsw.FireEventCounted(fireCtx, "event", nil)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 4, skipped)
+
+	assert.ErrorIs(t, evsw.FireEvent(fireCtx, "event", nil), context.Canceled)
+	assert.Equal(t, 1, calls)
+
+	// FireEvent stops the same way and reports why
+	calls = 0
+	fireCtx, fireCancel = context.WithCancel(ctx)
+	defer fireCancel()
+	assert.ErrorIs(t, evsw.FireEvent(fireCtx, "event", nil), context.Canceled)
+	assert.Equal(t, 1, calls)
+
+	assert.NoError(t, evsw.FireEvent(ctx, "other", nil))
+}
+
+func TestFireEventAwait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errPersist := errors.New("disk full")
+	release := make(chan struct{})
+	slowDone := make(chan EventData, 1)
+	persisted := make(chan EventData, 1)
+	require.NoError(t, evsw.AddListenerForEvent("slow", "event",
+		func(_ context.Context, data EventData) error {
+			<-release
+			slowDone <- data
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("persister", "event",
+		func(_ context.Context, data EventData) error {
+			persisted <- data
+			if data == "fail" {
+				return errPersist
+			}
+			return nil
+		}))
+
+	// the awaited listener has run when FireEventAwait returns, while the
+	// slow one is still blocked
+	require.NoError(t, evsw.FireEventAwait(ctx, "event", "ok", "persister"))
+	assert.Equal(t, "ok", <-persisted)
+	assert.Empty(t, slowDone)
+	close(release)
+	assert.Equal(t, "ok", <-slowDone)
+
+	require.ErrorIs(t, evsw.FireEventAwait(ctx, "event", "fail", "persister"), errPersist)
+	<-persisted
+	<-slowDone
+
+	err := evsw.FireEventAwait(ctx, "event", "missing", "unknown")
+	require.Equal(t, ErrListenerNotSubscribed{listenerID: "unknown", event: "event"}, err)
+	assert.Equal(t, "missing", <-slowDone)
+	assert.Equal(t, "missing", <-persisted)
+
+	// cancelling the fire once it returned does not keep the event from
+	// the other listeners
+	fireCtx, cancelFire := context.WithCancel(ctx)
+	gate := make(chan struct{})
+	lastDone := make(chan EventData, 1)
+	require.NoError(t, evsw.AddListenerForEvent("awaited", "other",
+		func(context.Context, EventData) error { return nil }))
+	require.NoError(t, evsw.AddListenerForEvent("gate", "other",
+		func(context.Context, EventData) error {
+			<-gate
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("last", "other",
+		func(ctx context.Context, data EventData) error {
+			if ctx.Err() == nil {
+				lastDone <- data
+			}
+			return nil
+		}))
+	require.NoError(t, evsw.FireEventAwait(fireCtx, "other", "cancelled", "awaited"))
+	cancelFire()
+	close(gate)
+	select {
+	case data := <-lastDone:
+		assert.Equal(t, "cancelled", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the other listeners missed the event")
+	}
+}
+
+func TestFireEventAwaitNotDelivered(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	_, err := evsw.SubscribeChan("listener", "event", ChanOptions{BufferSize: 0, OnFull: DropNewest})
+	require.NoError(t, err)
+	require.ErrorIs(t, evsw.FireEventAwait(ctx, "event", nil, "listener"), ErrNotDelivered)
+
+	fireCtx, fireCancel := context.WithCancel(ctx)
+	fireCancel()
+	require.ErrorIs(t, evsw.FireEventAwait(fireCtx, "event", nil, "listener"), ErrNotDelivered)
+
+	// a rejected fire is not delivered either, but the listener is still
+	// reported as subscribed
+	evsw.DisableEvent("event")
+	require.ErrorIs(t, evsw.FireEventAwait(ctx, "event", nil, "listener"), ErrNotDelivered)
+	require.Equal(t, ErrListenerNotSubscribed{listenerID: "other", event: "event"},
+		evsw.FireEventAwait(ctx, "event", nil, "other"))
+}
+
+func TestFireFromChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received []EventData
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+
+	// returns once the channel is closed
+	ch := make(chan EventData, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	evsw.FireFromChannel(ctx, "event", ch)
+	assert.Equal(t, []EventData{1, 2, 3}, received)
+
+	// and once ctx is done, even if the channel stays open
+	fireCtx, fireCancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evsw.FireFromChannel(fireCtx, "event", make(chan EventData))
+	}()
+	fireCancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("FireFromChannel did not return on cancel")
+	}
+}
+
+func TestFireEventFirstSuccess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.ErrorIs(t, evsw.FireEventFirstSuccess(ctx, "event", nil), ErrNotDelivered)
+
+	var invoked []string
+	errPrimary := errors.New("primary unavailable")
+	require.NoError(t, evsw.AddListenerForEvent("primary", "event",
+		func(context.Context, EventData) error {
+			invoked = append(invoked, "primary")
+			return errPrimary
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("fallback", "event",
+		func(context.Context, EventData) error {
+			invoked = append(invoked, "fallback")
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("last resort", "event",
+		func(context.Context, EventData) error {
+			invoked = append(invoked, "last resort")
+			return nil
+		}))
+
+	require.NoError(t, evsw.FireEventFirstSuccess(ctx, "event", nil))
+	assert.Equal(t, []string{"primary", "fallback"}, invoked)
+}
+
+func TestFireEventFirstSuccessAllFail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	require.NoError(t, evsw.AddListenerForEvent("first", "event",
+		func(context.Context, EventData) error { return errFirst }))
+	require.NoError(t, evsw.AddListenerForEvent("second", "event",
+		func(context.Context, EventData) error { return errSecond }))
+
+	err := evsw.FireEventFirstSuccess(ctx, "event", nil)
+	var errs ListenerErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Equal(t, ListenerErrors{errFirst, errSecond}, errs)
+	assert.ErrorIs(t, err, errSecond)
+	assert.EqualError(t, err, "all listeners failed: first; second")
+
+	// a dropped event neither succeeds nor fails
+	_, err = evsw.SubscribeChan("chan", "dropping", ChanOptions{OnFull: DropNewest})
+	require.NoError(t, err)
+	assert.ErrorIs(t, evsw.FireEventFirstSuccess(ctx, "dropping", nil), ErrNotDelivered)
+}
+
+type fireTestKey struct{}
+
+func TestFireEventPropagatesContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	fireCtx, fireCancel := context.WithTimeout(context.WithValue(ctx, fireTestKey{}, "request"), time.Minute)
+	defer fireCancel()
+	wantDeadline, _ := fireCtx.Deadline()
+
+	const numListeners = 3
+	for i := 0; i < numListeners; i++ {
+		require.NoError(t, evsw.AddListenerForEvent(fmt.Sprintf("listener%d", i), "event",
+			func(ctx context.Context, _ EventData) error {
+				assert.Equal(t, "request", ctx.Value(fireTestKey{}))
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				assert.Equal(t, wantDeadline, deadline)
+				return nil
+			}))
+	}
+	delivered, _ := evsw.FireEventCounted(fireCtx, "event", nil)
+	assert.Equal(t, numListeners, delivered)
+
+	// cancelling the caller's context cancels the callback's
+	require.NoError(t, evsw.AddListenerForEvent("blocking", "blocking",
+		func(ctx context.Context, _ EventData) error {
+			fireCancel()
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(10 * time.Second):
+				return errors.New("not cancelled")
+			}
+		}))
+	start := time.Now()
+	evsw.FireEvent(fireCtx, "blocking", nil)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestFireEventDetached(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	received := make(chan context.Context, 1)
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(ctx context.Context, _ EventData) error {
+			received <- ctx
+			return nil
+		}))
+
+	evsw.FireEventDetached("event", nil)
+	cbCtx := <-received
+	assert.NoError(t, cbCtx.Err())
+	_, ok := cbCtx.Deadline()
+	assert.False(t, ok)
+
+	// the context ends with the switch
+	cancel()
+	evsw.Wait()
+	assert.Error(t, cbCtx.Err())
+}
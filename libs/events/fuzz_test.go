@@ -0,0 +1,79 @@
+//go:build go1.18
+// +build go1.18
+
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// FuzzEventMatching checks that a listener subscribed to an event receives
+// the fires of exactly that event, and that prefix removal removes exactly
+// the subscriptions whose event names have the prefix.
+func FuzzEventMatching(f *testing.F) {
+	for _, seed := range [][3]string{
+		{"", "", ""},
+		{"event", "event", "event"},
+		{"event", "Event", "ev"},
+		{"event", "event ", "event "},
+		{"a/b", "a/b/c", "a/"},
+		{"héllo", "héllo", "h"},
+		{"\x00", "", "\x00"},
+		{"\xff", "\xff", "\xfe"},
+	} {
+		f.Add(seed[0], seed[1], seed[2])
+	}
+
+	f.Fuzz(func(t *testing.T, subscribed, fired, prefix string) {
+		ctx := context.Background()
+		evsw := NewEventSwitch(log.NewNopLogger())
+
+		received := 0
+		if err := evsw.AddListenerForEvent("listener", subscribed,
+			func(_ context.Context, data EventData) error {
+				if data != fired {
+					t.Errorf("received data %q, want %q", data, fired)
+				}
+				received++
+				return nil
+			}); err != nil {
+			t.Fatal(err)
+		}
+
+		if names := evsw.EventNames(); len(names) != 1 || names[0] != subscribed {
+			t.Fatalf("EventNames() = %q, want [%q]", names, subscribed)
+		}
+
+		evsw.FireEvent(ctx, fired, fired)
+		if want := boolToInt(fired == subscribed); received != want {
+			t.Fatalf("firing %q with a listener of %q: %d deliveries, want %d",
+				fired, subscribed, received, want)
+		}
+
+		removed := evsw.RemoveListenersForEventPrefix(prefix)
+		matches := strings.HasPrefix(subscribed, prefix)
+		if removed != boolToInt(matches) {
+			t.Fatalf("removing prefix %q from %q removed %d listeners", prefix, subscribed, removed)
+		}
+		if left := len(evsw.Listeners(subscribed)); left != boolToInt(!matches) {
+			t.Fatalf("%d listeners left on %q after removing prefix %q", left, subscribed, prefix)
+		}
+
+		received = 0
+		evsw.FireEvent(ctx, subscribed, fired)
+		if received != boolToInt(!matches) {
+			t.Fatalf("%d deliveries after removing prefix %q from %q", received, prefix, subscribed)
+		}
+	})
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
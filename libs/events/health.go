@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownListener is returned by SetHealthCheck if the switch knows no
+// listener with the given ID, whether or not it is subscribed to any event.
+var ErrUnknownListener = errors.New("unknown listener")
+
+// HealthCheck reports whether a listener is healthy, see SetHealthCheck.
+type HealthCheck func(ctx context.Context) error
+
+// ErrHealthCheckPanicked is reported by CheckListeners for a listener whose
+// health check panicked.
+type ErrHealthCheckPanicked struct {
+	Value interface{}
+}
+
+// Error implements the error interface.
+func (e ErrHealthCheckPanicked) Error() string {
+	return fmt.Sprintf("health check panicked: %v", e.Value)
+}
+
+func (evsw *eventSwitch) SetHealthCheck(listenerID string, check HealthCheck) error {
+	evsw.mtx.RLock()
+	listener := evsw.listeners[listenerID]
+	evsw.mtx.RUnlock()
+	if listener == nil {
+		return ErrUnknownListener
+	}
+
+	listener.mtx.Lock()
+	listener.healthCheck = check
+	listener.mtx.Unlock()
+	return nil
+}
+
+func (evsw *eventSwitch) CheckListeners(ctx context.Context) map[string]error {
+	evsw.mtx.RLock()
+	checks := make(map[string]HealthCheck, len(evsw.listeners))
+	for listenerID, listener := range evsw.listeners {
+		listener.mtx.RLock()
+		checks[listenerID] = listener.healthCheck
+		listener.mtx.RUnlock()
+	}
+	evsw.mtx.RUnlock()
+
+	var (
+		mtx     sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(checks))
+	)
+	for listenerID, check := range checks {
+		if check == nil {
+			mtx.Lock()
+			results[listenerID] = nil
+			mtx.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(listenerID string, check HealthCheck) {
+			defer wg.Done()
+			err := evsw.runHealthCheck(ctx, listenerID, check)
+			mtx.Lock()
+			results[listenerID] = err
+			mtx.Unlock()
+		}(listenerID, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// runHealthCheck runs the health check of a listener, turning a panic into
+// an ErrHealthCheckPanicked.
+func (evsw *eventSwitch) runHealthCheck(ctx context.Context, listenerID string, check HealthCheck) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			evsw.logger.Error("listener health check panicked",
+				"listener", listenerID, "panic", fmt.Sprint(r))
+			err = ErrHealthCheckPanicked{Value: r}
+		}
+	}()
+	return check(ctx)
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestCheckListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	noop := func(context.Context, EventData) error { return nil }
+	for _, listenerID := range []string{"healthy", "unhealthy", "unchecked"} {
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "event", noop))
+	}
+
+	errUnhealthy := errors.New("unhealthy")
+	// Both checks wait for each other, so they pass only if run
+	// concurrently.
+	started := make(chan struct{}, 2)
+	waitOther := func(ctx context.Context) error {
+		started <- struct{}{}
+		for len(started) < 2 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return nil
+	}
+	require.NoError(t, evsw.SetHealthCheck("healthy", waitOther))
+	require.NoError(t, evsw.SetHealthCheck("unhealthy", func(ctx context.Context) error {
+		if err := waitOther(ctx); err != nil {
+			return err
+		}
+		return errUnhealthy
+	}))
+	require.ErrorIs(t, evsw.SetHealthCheck("unknown", waitOther), ErrUnknownListener)
+
+	checkCtx, checkCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer checkCancel()
+	assert.Equal(t, map[string]error{
+		"healthy":   nil,
+		"unhealthy": errUnhealthy,
+		"unchecked": nil,
+	}, evsw.CheckListeners(checkCtx))
+
+	// the check goes away with the listener, or when cleared
+	evsw.RemoveListener("unhealthy")
+	require.NoError(t, evsw.SetHealthCheck("healthy", nil))
+	assert.Equal(t, map[string]error{"healthy": nil, "unchecked": nil}, evsw.CheckListeners(ctx))
+	require.ErrorIs(t, evsw.SetHealthCheck("unhealthy", waitOther), ErrUnknownListener)
+
+	// a listener without subscriptions is still known
+	evsw.RemoveListenerForEvent("event", "unchecked")
+	require.NoError(t, evsw.SetHealthCheck("unchecked", nil))
+
+	// a panicking check reports the listener as unhealthy
+	require.NoError(t, evsw.SetHealthCheck("healthy", func(context.Context) error {
+		panic("broken")
+	}))
+	assert.Equal(t, map[string]error{
+		"healthy":   ErrHealthCheckPanicked{Value: "broken"},
+		"unchecked": nil,
+	}, evsw.CheckListeners(ctx))
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// ListenerLagging is fired by the switch when the buffer of a channel
+// subscription (see SubscribeChan) stays nearly full for a sustained period.
+// The event data is a ListenerLaggingData.
+const ListenerLagging = "events/listener_lagging"
+
+const (
+	// defaultLagSampleInterval is how often channel buffers are sampled.
+	defaultLagSampleInterval = 100 * time.Millisecond
+	// defaultLagSamples is the number of consecutive nearly-full samples
+	// after which a subscription is reported as lagging.
+	defaultLagSamples = 10
+)
+
+// ListenerLaggingData is the event data of ListenerLagging.
+type ListenerLaggingData struct {
+	ListenerID string
+	Event      string
+	BufferLen  int
+	BufferCap  int
+}
+
+// nearlyFull reports whether a buffer holding n of c elements counts as
+// nearly full, i.e. at least 90% occupied.
+func nearlyFull(n, c int) bool {
+	return c > 0 && n*10 >= c*9
+}
+
+// monitorLag periodically samples the occupancy of every buffered channel
+// subscription until ctx is done, and fires ListenerLagging once a
+// subscription has been nearly full for lagSamples consecutive samples. It
+// fires again for the same subscription only after it has recovered.
+func (evsw *eventSwitch) monitorLag(ctx context.Context) {
+	timer := evsw.clock.NewTimer(evsw.lagSampleInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+		}
+
+		for _, lag := range evsw.sampleLag() {
+			_ = evsw.FireEvent(ctx, ListenerLagging, lag)
+		}
+		timer.Reset(evsw.lagSampleInterval)
+	}
+}
+
+// sampleLag records one occupancy sample for each channel subscription and
+// returns the subscriptions that just became lagging. Only monitorLag calls
+// it, so the sampling state of the subscriptions needs no locking.
+func (evsw *eventSwitch) sampleLag() []ListenerLaggingData {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	var lagging []ListenerLaggingData
+	for key, sub := range evsw.chanSubs {
+		// Never report on subscribers of the lagging event itself, since
+		// firing the report would only add to their backlog.
+		if key.event == ListenerLagging {
+			continue
+		}
+
+		n, c := len(sub.ch), cap(sub.ch)
+		if !nearlyFull(n, c) {
+			sub.fullSamples = 0
+			sub.lagging = false
+			continue
+		}
+
+		sub.fullSamples++
+		if sub.fullSamples >= evsw.lagSamples && !sub.lagging {
+			sub.lagging = true
+			lagging = append(lagging, ListenerLaggingData{
+				ListenerID: key.listenerID,
+				Event:      key.event,
+				BufferLen:  n,
+				BufferCap:  c,
+			})
+		}
+	}
+	return lagging
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestListenerLagging(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock))
+	impl := evsw.(*eventSwitch)
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	// sample advances the clock to the next sample and waits for it to be
+	// taken.
+	sample := func() {
+		clock.waitForTimers(t, 1)
+		clock.Advance(impl.lagSampleInterval)
+		clock.waitForTimers(t, 1)
+	}
+
+	var reports []ListenerLaggingData
+	require.NoError(t, evsw.AddListenerForEvent("monitor", ListenerLagging,
+		func(_ context.Context, data EventData) error {
+			reports = append(reports, data.(ListenerLaggingData))
+			return nil
+		}))
+	// a lagging subscriber to the lagging event must not be reported
+	_, err := evsw.SubscribeChan("self", ListenerLagging, ChanOptions{BufferSize: 1, OnFull: DropNewest})
+	require.NoError(t, err)
+
+	ch, err := evsw.SubscribeChan("slow", "event", ChanOptions{BufferSize: 2, OnFull: DropNewest})
+	require.NoError(t, err)
+	evsw.FireEvent(ctx, "event", 1)
+	evsw.FireEvent(ctx, "event", 2)
+
+	for i := 0; i < impl.lagSamples-1; i++ {
+		sample()
+	}
+	require.Empty(t, reports, "transient backlog must not be reported")
+	sample()
+	require.Equal(t, []ListenerLaggingData{
+		{ListenerID: "slow", Event: "event", BufferLen: 2, BufferCap: 2},
+	}, reports)
+
+	// no repeated reports while the subscription stays full
+	for i := 0; i < 2*impl.lagSamples; i++ {
+		sample()
+	}
+	require.Len(t, reports, 1)
+
+	// recovery re-arms the report
+	<-ch
+	<-ch
+	sample()
+	evsw.FireEvent(ctx, "event", 3)
+	evsw.FireEvent(ctx, "event", 4)
+	for i := 0; i < impl.lagSamples; i++ {
+		sample()
+	}
+	require.Len(t, reports, 2)
+	assert.Equal(t, "slow", reports[1].ListenerID)
+}
+
+func TestListenerLaggingRealClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	impl := evsw.(*eventSwitch)
+	impl.lagSampleInterval = time.Millisecond
+	impl.lagSamples = 3
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	reports := make(chan ListenerLaggingData, 1)
+	require.NoError(t, evsw.AddListenerForEvent("monitor", ListenerLagging,
+		func(_ context.Context, data EventData) error {
+			reports <- data.(ListenerLaggingData)
+			return nil
+		}))
+	_, err := evsw.SubscribeChan("slow", "event", ChanOptions{BufferSize: 1, OnFull: DropNewest})
+	require.NoError(t, err)
+	evsw.FireEvent(ctx, "event", 1)
+
+	select {
+	case lag := <-reports:
+		assert.Equal(t, "slow", lag.ListenerID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a lagging report")
+	}
+}
+
+func TestNearlyFull(t *testing.T) {
+	assert.False(t, nearlyFull(0, 0))
+	assert.False(t, nearlyFull(8, 10))
+	assert.True(t, nearlyFull(9, 10))
+	assert.True(t, nearlyFull(1, 1))
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// fireLatencies tracks how long the most recent fires of each event took
+// to be delivered to its listeners. The events tracked are bounded like the
+// cached values, by WithMaxCachedEvents.
+type fireLatencies struct {
+	window int
+
+	mtx sync.Mutex
+	// rings maps events to their *latencyRing.
+	rings eventLRU
+}
+
+func newFireLatencies(window int) *fireLatencies {
+	return &fireLatencies{window: window}
+}
+
+func (fl *fireLatencies) record(event string, latency time.Duration) {
+	fl.mtx.Lock()
+	defer fl.mtx.Unlock()
+
+	ring, _ := fl.rings.get(event).(*latencyRing)
+	if ring == nil {
+		ring = &latencyRing{samples: make([]time.Duration, fl.window)}
+		fl.rings.set(event, ring)
+	}
+	ring.add(latency)
+}
+
+// sorted returns the retained latencies of event in increasing order.
+func (fl *fireLatencies) sorted(event string) []time.Duration {
+	fl.mtx.Lock()
+	ring, _ := fl.rings.get(event).(*latencyRing)
+	var samples []time.Duration
+	if ring != nil {
+		samples = append(samples, ring.samples[:ring.size]...)
+	}
+	fl.mtx.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples
+}
+
+// latencyRing is a fixed-size ring of fire latencies.
+type latencyRing struct {
+	samples []time.Duration
+	next    int
+	size    int
+}
+
+func (r *latencyRing) add(latency time.Duration) {
+	r.samples[r.next] = latency
+	r.next = (r.next + 1) % len(r.samples)
+	if r.size < len(r.samples) {
+		r.size++
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies, by the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (evsw *eventSwitch) LatencyPercentiles(event string) (p50, p95, p99 time.Duration) {
+	if evsw.latencies == nil {
+		return 0, 0, 0
+	}
+	sorted := evsw.latencies.sorted(event)
+	if len(sorted) == 0 {
+		return 0, 0, 0
+	}
+	return percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99)
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithLatencyWindow(100))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			clock.Advance(time.Duration(data.(int)) * time.Millisecond)
+			return nil
+		}))
+
+	p50, p95, p99 := evsw.LatencyPercentiles("event")
+	assert.Zero(t, p50)
+	assert.Zero(t, p95)
+	assert.Zero(t, p99)
+
+	// fire i takes i milliseconds
+	for i := 1; i <= 100; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	p50, p95, p99 = evsw.LatencyPercentiles("event")
+	assert.Equal(t, 50*time.Millisecond, p50)
+	assert.Equal(t, 95*time.Millisecond, p95)
+	assert.Equal(t, 99*time.Millisecond, p99)
+
+	// only the last 100 fires are retained
+	for i := 0; i < 100; i++ {
+		evsw.FireEvent(ctx, "event", 1)
+	}
+	p50, p95, p99 = evsw.LatencyPercentiles("event")
+	assert.Equal(t, time.Millisecond, p50)
+	assert.Equal(t, time.Millisecond, p95)
+	assert.Equal(t, time.Millisecond, p99)
+
+	p50, _, _ = evsw.LatencyPercentiles("other")
+	assert.Zero(t, p50)
+}
+
+func TestLatencyPercentilesEviction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithMaxCachedEvents(2), WithLatencyWindow(10))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	for _, event := range []string{"a", "b", "c"} {
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(context.Context, EventData) error {
+				clock.Advance(time.Millisecond)
+				return nil
+			}))
+	}
+
+	evsw.FireEvent(ctx, "a", nil)
+	evsw.FireEvent(ctx, "b", nil)
+	p50, _, _ := evsw.LatencyPercentiles("a")
+	assert.Equal(t, time.Millisecond, p50)
+
+	// c evicts b, the least recently used event
+	evsw.FireEvent(ctx, "c", nil)
+	p50, _, _ = evsw.LatencyPercentiles("b")
+	assert.Zero(t, p50)
+	for _, event := range []string{"a", "c"} {
+		p50, _, _ = evsw.LatencyPercentiles(event)
+		assert.Equal(t, time.Millisecond, p50, event)
+	}
+}
+
+func TestLatencyPercentilesDisabled(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	evsw.FireEvent(context.Background(), "event", nil)
+
+	p50, p95, p99 := evsw.LatencyPercentiles("event")
+	assert.Zero(t, p50)
+	assert.Zero(t, p95)
+	assert.Zero(t, p99)
+}
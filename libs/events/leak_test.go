@@ -0,0 +1,33 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/events"
+	"github.com/tendermint/tendermint/libs/events/eventstest"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestSwitchLeaksNoGoroutines(t *testing.T) {
+	eventstest.AssertNoLeaks(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		evsw := events.NewEventSwitch(log.TestingLogger(), events.WithLifecycleEvents())
+		require.NoError(t, evsw.Start(ctx))
+
+		_, err := evsw.SubscribeChan("chan", "event", events.ChanOptions{BufferSize: 1, OnFull: events.DropNewest})
+		require.NoError(t, err)
+		require.NoError(t, evsw.AddBatchingListener("batch", "event", time.Hour, 10,
+			func(context.Context, []events.EventData) error { return nil }))
+		require.NoError(t, evsw.FireEventParallel(ctx, "event", nil))
+		evsw.FireEvent(ctx, "event", nil)
+
+		require.NoError(t, evsw.Shutdown(ctx))
+		evsw.Wait()
+	})
+}
@@ -0,0 +1,24 @@
+package events
+
+import "context"
+
+// Lifecycle events fired by a switch created with WithLifecycleEvents. Their
+// event data is nil.
+const (
+	// SwitchStarted is fired once the switch has started.
+	SwitchStarted = "switch/started"
+	// SwitchStopping is fired when the switch begins to stop, while its
+	// context is still live.
+	SwitchStopping = "switch/stopping"
+	// SwitchStopped is fired once the switch has stopped. Its listeners are
+	// called with a background context, since the switch context has been
+	// cancelled by then.
+	SwitchStopped = "switch/stopped"
+)
+
+// fireLifecycle fires a lifecycle event if they are enabled.
+func (evsw *eventSwitch) fireLifecycle(ctx context.Context, event string) {
+	if evsw.lifecycleEvents {
+		_ = evsw.FireEvent(ctx, event, nil)
+	}
+}
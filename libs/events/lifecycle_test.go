@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithLifecycleEvents())
+
+	var events []string
+	for _, event := range []string{SwitchStarted, SwitchStopping, SwitchStopped} {
+		event := event
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(ctx context.Context, _ EventData) error {
+				events = append(events, event)
+				if event == SwitchStopping {
+					assert.NoError(t, ctx.Err(), "stopping should be fired before the context is cancelled")
+				}
+				return nil
+			}))
+	}
+
+	require.NoError(t, evsw.Start(ctx))
+	assert.Equal(t, []string{SwitchStarted}, events)
+
+	require.NoError(t, evsw.Stop())
+	evsw.Wait()
+	assert.Equal(t, []string{SwitchStarted, SwitchStopping, SwitchStopped}, events)
+}
+
+func TestLifecycleEventsDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.AddListenerForEvent("listener", SwitchStarted,
+		func(context.Context, EventData) error {
+			t.Error("lifecycle events are opt-in")
+			return nil
+		}))
+
+	require.NoError(t, evsw.Start(ctx))
+	require.NoError(t, evsw.Stop())
+	evsw.Wait()
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// defaultLogSampleInterval is the interval over which similar log lines
+	// of the switch are logged only once.
+	defaultLogSampleInterval = 10 * time.Second
+	// maxLogSamples is the number of log sampling entries above which the
+	// expired ones are dropped.
+	maxLogSamples = 1024
+)
+
+// samplingLogger rate-limits the log lines of the switch: of the similar
+// lines logged at info or error level during an interval, only the first is
+// written, and the next written line reports how many were suppressed in
+// between. Lines are similar if they have the same message, event, listener
+// and method and their errors have the same type.
+type samplingLogger struct {
+	log.Logger
+	samples *logSamples
+}
+
+type logSamples struct {
+	clock    Clock
+	interval time.Duration
+
+	mtx sync.Mutex
+	m   map[string]*logSample
+}
+
+type logSample struct {
+	start      time.Time // when the current interval began
+	suppressed int
+}
+
+func newSamplingLogger(logger log.Logger, clock Clock, interval time.Duration) log.Logger {
+	return samplingLogger{
+		Logger: logger,
+		samples: &logSamples{
+			clock:    clock,
+			interval: interval,
+			m:        make(map[string]*logSample),
+		},
+	}
+}
+
+func (l samplingLogger) Info(msg string, keyVals ...interface{}) {
+	if keyVals, ok := l.samples.sample(msg, keyVals); ok {
+		l.Logger.Info(msg, keyVals...)
+	}
+}
+
+func (l samplingLogger) Error(msg string, keyVals ...interface{}) {
+	if keyVals, ok := l.samples.sample(msg, keyVals); ok {
+		l.Logger.Error(msg, keyVals...)
+	}
+}
+
+func (l samplingLogger) With(keyVals ...interface{}) log.Logger {
+	return samplingLogger{Logger: l.Logger.With(keyVals...), samples: l.samples}
+}
+
+// sample reports whether a line is to be logged and returns its key-value
+// pairs, along with the number of similar lines suppressed before it.
+func (s *logSamples) sample(msg string, keyVals []interface{}) ([]interface{}, bool) {
+	key := sampleKey(msg, keyVals)
+	now := s.clock.Now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	sample := s.m[key]
+	if sample != nil && now.Sub(sample.start) < s.interval {
+		sample.suppressed++
+		return nil, false
+	}
+
+	if sample == nil {
+		if len(s.m) >= maxLogSamples {
+			s.prune(now)
+		}
+		sample = &logSample{}
+		s.m[key] = sample
+	}
+	if sample.suppressed > 0 {
+		keyVals = append(keyVals[:len(keyVals):len(keyVals)], "suppressed", sample.suppressed)
+	}
+	sample.start = now
+	sample.suppressed = 0
+	return keyVals, true
+}
+
+// prune drops the entries whose interval has expired at now. s.mtx must be
+// held.
+func (s *logSamples) prune(now time.Time) {
+	for key, sample := range s.m {
+		if now.Sub(sample.start) >= s.interval {
+			delete(s.m, key)
+		}
+	}
+}
+
+// sampleKey identifies the log lines similar to the given one.
+func sampleKey(msg string, keyVals []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(keyVals); i += 2 {
+		switch k := keyVals[i]; k {
+		case "event", "listener", "method":
+			fmt.Fprintf(&b, "\x00%v=%v", k, keyVals[i+1])
+		case "err":
+			fmt.Fprintf(&b, "\x00err=%T", keyVals[i+1])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func failureLines(logger *recordingLogger) []string {
+	var lines []string
+	for _, msg := range logger.messages() {
+		if strings.Contains(msg, "event callback failed") {
+			lines = append(lines, msg)
+		}
+	}
+	return lines
+}
+
+func TestLogSampling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(logger, WithClock(clock), WithLogSampling(10*time.Second))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errFailed := errors.New("failed")
+	for _, listenerID := range []string{"a", "b"} {
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "event",
+			func(context.Context, EventData) error { return errFailed }))
+	}
+
+	for i := 0; i < 100; i++ {
+		evsw.FireEvent(ctx, "event", nil)
+	}
+	// one line per listener
+	lines := failureLines(logger)
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "listener a")
+	assert.Contains(t, lines[1], "listener b")
+
+	clock.Advance(10 * time.Second)
+	evsw.FireEvent(ctx, "event", nil)
+	lines = failureLines(logger)
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[2], "listener a event event err failed suppressed 99")
+}
+
+func TestLogSamplingDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(logger, WithLogSampling(0))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	for i := 0; i < 10; i++ {
+		evsw.FireEvent(ctx, "event", nil)
+	}
+	assert.Len(t, failureLines(logger), 10)
+}
+
+func TestSampleKey(t *testing.T) {
+	key := sampleKey("msg", []interface{}{"event", "e", "listener", "l", "err", errors.New("x"), "duration", 1})
+	// error messages and other values do not matter
+	assert.Equal(t, key,
+		sampleKey("msg", []interface{}{"event", "e", "listener", "l", "err", errors.New("y"), "duration", 2}))
+	assert.NotEqual(t, key,
+		sampleKey("msg", []interface{}{"event", "e", "listener", "l", "err", ErrCallbackPanicked{}}))
+	assert.NotEqual(t, key, sampleKey("msg", []interface{}{"event", "other", "listener", "l"}))
+}
@@ -0,0 +1,55 @@
+package events
+
+import "container/list"
+
+// eventLRU maps event names to data, evicting the least recently used
+// events once it holds more than max of them. It is not safe for concurrent
+// use.
+type eventLRU struct {
+	// max is the number of events retained; zero means no bound.
+	max       int
+	order     *list.List
+	entries   map[string]*list.Element
+	evictions uint64
+}
+
+type lruEntry struct {
+	event string
+	data  EventData
+}
+
+// get returns the data of event, or nil if there is none, and marks event
+// as recently used.
+func (c *eventLRU) get(event string) EventData {
+	elem, ok := c.entries[event]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).data
+}
+
+// set sets the data of event, evicting the least recently used event if the
+// bound is exceeded.
+func (c *eventLRU) set(event string, data EventData) {
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.order = list.New()
+	}
+	if elem, ok := c.entries[event]; ok {
+		elem.Value.(*lruEntry).data = data
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[event] = c.order.PushFront(&lruEntry{event: event, data: data})
+	if c.max > 0 && c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).event)
+		c.evictions++
+	}
+}
+
+func (c *eventLRU) len() int {
+	return len(c.entries)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLRU(t *testing.T) {
+	c := eventLRU{max: 2}
+	assert.Nil(t, c.get("a"))
+
+	c.set("a", 1)
+	c.set("b", 2)
+	assert.Equal(t, 1, c.get("a")) // b is now the least recently used
+
+	c.set("c", 3)
+	assert.Equal(t, 2, c.len())
+	assert.Equal(t, uint64(1), c.evictions)
+	assert.Nil(t, c.get("b"))
+	assert.Equal(t, 1, c.get("a"))
+	assert.Equal(t, 3, c.get("c"))
+
+	// updating an event does not evict anything
+	c.set("a", 10)
+	assert.Equal(t, 10, c.get("a"))
+	assert.Equal(t, uint64(1), c.evictions)
+}
+
+func TestEventLRUUnbounded(t *testing.T) {
+	var c eventLRU
+	for i := 0; i < 100; i++ {
+		c.set(string(rune('a'+i%26))+string(rune('a'+i/26)), i)
+	}
+	assert.Equal(t, 100, c.len())
+	assert.Zero(t, c.evictions)
+}
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+)
+
+// EventMarshaler is implemented by event data controlling its own wire
+// representation, e.g. to version it.
+type EventMarshaler interface {
+	MarshalEvent() ([]byte, error)
+}
+
+// protoMarshaler marshals protobuf messages as canonical proto-JSON.
+var protoMarshaler = jsonpb.Marshaler{}
+
+// MarshalEventData serializes event data for the paths that export events
+// out of the process. It prefers the data's own EventMarshaler
+// implementation, marshals protobuf messages as proto-JSON, matching their
+// wire format elsewhere, and falls back to encoding/json. The data is only
+// read: the fire path hands a message to the listeners as is, without
+// copying it, and so does MarshalEventData.
+func MarshalEventData(data EventData) ([]byte, error) {
+	switch data := data.(type) {
+	case EventMarshaler:
+		return data.MarshalEvent()
+	case proto.Message:
+		var buf bytes.Buffer
+		if err := protoMarshaler.Marshal(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+)
+
+type versionedHeight int64
+
+func (h versionedHeight) MarshalEvent() ([]byte, error) {
+	if h < 0 {
+		return nil, errors.New("negative height")
+	}
+	return []byte(fmt.Sprintf(`{"v":2,"height":%d}`, h)), nil
+}
+
+func TestMarshalEventData(t *testing.T) {
+	b, err := MarshalEventData(versionedHeight(7))
+	require.NoError(t, err)
+	assert.Equal(t, `{"v":2,"height":7}`, string(b))
+
+	_, err = MarshalEventData(versionedHeight(-1))
+	require.EqualError(t, err, "negative height")
+
+	// other data falls back to encoding/json
+	b, err = MarshalEventData(struct {
+		Height int64 `json:"height"`
+	}{7})
+	require.NoError(t, err)
+	assert.Equal(t, `{"height":7}`, string(b))
+
+	b, err = MarshalEventData(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+}
+
+func TestMarshalEventDataProto(t *testing.T) {
+	vote := &tmproto.Vote{
+		Type:    tmproto.PrevoteType,
+		Height:  7,
+		Round:   1,
+		BlockID: tmproto.BlockID{Hash: []byte{1, 2}},
+	}
+
+	// proto-JSON, not the reflection-based encoding/json output
+	b, err := MarshalEventData(vote)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "SIGNED_MSG_TYPE_PREVOTE",
+		"height": "7",
+		"round": 1,
+		"blockId": {"hash": "AQI=", "partSetHeader": {}},
+		"timestamp": "0001-01-01T00:00:00Z"
+	}`, string(b))
+
+	// the listeners receive the message itself
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	var received EventData
+	require.NoError(t, evsw.AddListenerForEvent("listener", "vote",
+		func(_ context.Context, data EventData) error {
+			received = data
+			return nil
+		}))
+	require.NoError(t, evsw.FireEvent(ctx, "vote", vote))
+	assert.Same(t, vote, received)
+}
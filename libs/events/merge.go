@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoEvents is returned by MergeChan when it is given no events.
+var ErrNoEvents = errors.New("no events to merge")
+
+// NamedEvent is a fire delivered by MergeChan, or queued by
+// FireEventNonBlocking as reported by PendingEvents.
+type NamedEvent struct {
+	Event string
+	Data  EventData
+	// Seq numbers the fires delivered on a merged channel, starting at 1.
+	// Fires are delivered in increasing order of Seq, with no gaps. For a
+	// queued fire, it numbers the fires queued by the switch.
+	Seq uint64
+	// Time is when a queued fire was queued. It is not set by MergeChan.
+	Time time.Time
+}
+
+// mergeSub is the subscription behind a merged channel.
+type mergeSub struct {
+	// mtx is held while sending, so that fires are numbered in the order
+	// they enter the channel, and by close.
+	mtx    sync.Mutex
+	seq    uint64
+	ch     chan NamedEvent
+	closed bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// callback returns the callback delivering the fires of event.
+func (m *mergeSub) callback(event string) EventCallback {
+	return func(ctx context.Context, data EventData) error {
+		m.mtx.Lock()
+		defer m.mtx.Unlock()
+
+		if m.closed {
+			return errEventDropped
+		}
+		select {
+		case m.ch <- NamedEvent{Event: event, Data: data, Seq: m.seq + 1}:
+			m.seq++
+			return nil
+		case <-m.done:
+		case <-ctx.Done():
+		}
+		return errEventDropped
+	}
+}
+
+// close closes the channel. Blocked senders are released first.
+func (m *mergeSub) close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+
+		m.mtx.Lock()
+		m.closed = true
+		close(m.ch)
+		m.mtx.Unlock()
+	})
+}
+
+// mergeID numbers the listeners registered by MergeChan.
+var mergeID uint64
+
+// MergeChan delivers the fires of all of events on one channel. A fire is
+// numbered when it enters the channel, so the sequence reflects the order in
+// which fires of different events reached the channel. While the buffer is
+// full, fires of the merged events wait for room or for the context of the
+// fire to be done, in which case the merged channel misses them.
+func (evsw *eventSwitch) MergeChan(ctx context.Context, events []string, capacity int) (<-chan NamedEvent, error) {
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	if capacity < 0 {
+		return nil, ErrInvalidBufferSize
+	}
+
+	listenerID := fmt.Sprintf("merge#%d", atomic.AddUint64(&mergeID, 1))
+	m := &mergeSub{
+		ch:   make(chan NamedEvent, capacity),
+		done: make(chan struct{}),
+	}
+	for _, event := range events {
+		if err := evsw.AddListenerForEvent(listenerID, event, m.callback(event)); err != nil {
+			evsw.RemoveListener(listenerID)
+			return nil, err
+		}
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-evsw.Done():
+		}
+		evsw.RemoveListener(listenerID)
+		m.close()
+	}()
+	return m.ch, nil
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestMergeChan(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	_, err := evsw.MergeChan(ctx, nil, 1)
+	require.ErrorIs(t, err, ErrNoEvents)
+	_, err = evsw.MergeChan(ctx, []string{"vote"}, -1)
+	require.ErrorIs(t, err, ErrInvalidBufferSize)
+
+	mergeCtx, mergeCancel := context.WithCancel(ctx)
+	ch, err := evsw.MergeChan(mergeCtx, []string{"vote", "proposal"}, 10)
+	require.NoError(t, err)
+
+	evsw.FireEvent(ctx, "vote", 1)
+	evsw.FireEvent(ctx, "proposal", 2)
+	evsw.FireEvent(ctx, "other", 3)
+	evsw.FireEvent(ctx, "vote", 4)
+
+	assert.Equal(t, NamedEvent{Event: "vote", Data: 1, Seq: 1}, <-ch)
+	assert.Equal(t, NamedEvent{Event: "proposal", Data: 2, Seq: 2}, <-ch)
+	assert.Equal(t, NamedEvent{Event: "vote", Data: 4, Seq: 3}, <-ch)
+
+	// cancelling closes the channel and removes the subscriptions
+	mergeCancel()
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("merged channel not closed on cancel")
+	}
+	assert.Empty(t, evsw.EventNames())
+}
+
+func TestMergeChanClosedOnStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	ch, err := evsw.MergeChan(ctx, []string{"vote"}, 0)
+	require.NoError(t, err)
+
+	// an unbuffered merge blocks the fire until it is received
+	go evsw.FireEvent(ctx, "vote", 1)
+	assert.Equal(t, NamedEvent{Event: "vote", Data: 1, Seq: 1}, <-ch)
+
+	require.NoError(t, evsw.Stop())
+	evsw.Wait()
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("merged channel not closed on stop")
+	}
+}
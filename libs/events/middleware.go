@@ -0,0 +1,56 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Middleware wraps the callbacks of listeners, see UseForEvent.
+type Middleware func(next EventCallback) EventCallback
+
+// eventMiddleware holds the middleware of each event. Like disabledEvents,
+// it is only consulted when it is not empty.
+type eventMiddleware struct {
+	count int32 // atomic
+
+	mtx sync.RWMutex
+	m   map[string][]Middleware
+}
+
+func (evsw *eventSwitch) UseForEvent(event string, mw Middleware) {
+	if mw == nil {
+		return
+	}
+
+	em := &evsw.middleware
+	em.mtx.Lock()
+	defer em.mtx.Unlock()
+
+	if em.m == nil {
+		em.m = make(map[string][]Middleware)
+	}
+	// Copy on write, so that fires can use the chain they got unlocked.
+	chain := make([]Middleware, len(em.m[event]), len(em.m[event])+1)
+	copy(chain, em.m[event])
+	em.m[event] = append(chain, mw)
+	atomic.AddInt32(&em.count, 1)
+}
+
+func (em *eventMiddleware) get(event string) []Middleware {
+	if atomic.LoadInt32(&em.count) == 0 {
+		return nil
+	}
+
+	em.mtx.RLock()
+	defer em.mtx.RUnlock()
+	return em.m[event]
+}
+
+// withMiddleware returns lc with its callback wrapped in chain, the first
+// middleware outermost.
+func withMiddleware(lc listenerCallback, chain []Middleware) listenerCallback {
+	for i := len(chain) - 1; i >= 0; i-- {
+		lc.cb = chain[i](lc.cb)
+	}
+	return lc
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestUseForEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var trace []string
+	tracing := func(name string) Middleware {
+		return func(next EventCallback) EventCallback {
+			return func(ctx context.Context, data EventData) error {
+				trace = append(trace, name)
+				return next(ctx, data)
+			}
+		}
+	}
+	errInvalid := errors.New("invalid tx")
+	validating := func(next EventCallback) EventCallback {
+		return func(ctx context.Context, data EventData) error {
+			if data == nil {
+				return errInvalid
+			}
+			return next(ctx, data)
+		}
+	}
+
+	for _, event := range []string{"tx", "block"} {
+		event := event
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(context.Context, EventData) error {
+				trace = append(trace, event)
+				return nil
+			}))
+	}
+	evsw.UseForEvent("tx", tracing("outer"))
+	evsw.UseForEvent("tx", tracing("inner"))
+	evsw.UseForEvent("tx", validating)
+	evsw.UseForEvent("tx", nil)
+
+	evsw.FireEvent(ctx, "tx", "valid")
+	evsw.FireEvent(ctx, "block", nil)
+	assert.Equal(t, []string{"outer", "inner", "tx", "block"}, trace)
+
+	trace = nil
+	evsw.FireEvent(ctx, "tx", nil)
+	assert.Equal(t, []string{"outer", "inner"}, trace)
+	assert.Equal(t, uint64(1), evsw.Report().CallbackErrors)
+}
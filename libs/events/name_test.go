@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// contextLogger is a recordingLogger that records the key-value pairs added
+// with With too.
+type contextLogger struct {
+	*recordingLogger
+	keyVals []interface{}
+}
+
+func (l contextLogger) Info(msg string, keyVals ...interface{}) {
+	l.recordingLogger.Info(msg, append(l.keyVals[:len(l.keyVals):len(l.keyVals)], keyVals...)...)
+}
+
+func (l contextLogger) Error(msg string, keyVals ...interface{}) {
+	l.Info(msg, keyVals...)
+}
+
+func (l contextLogger) With(keyVals ...interface{}) log.Logger {
+	return contextLogger{recordingLogger: l.recordingLogger, keyVals: append(l.keyVals, keyVals...)}
+}
+
+func TestWithName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(contextLogger{recordingLogger: logger}, WithName("mempool"))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	evsw.FireEvent(ctx, "event", nil)
+
+	messages := logger.messages()
+	require.NotEmpty(t, messages)
+	for _, msg := range messages {
+		assert.Contains(t, msg, "switch mempool")
+	}
+
+	assert.Equal(t, "mempool", evsw.Stats().Name)
+	assert.Equal(t, "mempool", evsw.Report().Name)
+	assert.Empty(t, NewEventSwitch(log.TestingLogger()).Report().Name)
+}
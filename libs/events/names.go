@@ -0,0 +1,27 @@
+package events
+
+import "context"
+
+// EventName is the name of an event. Using the typed constants below instead
+// of raw strings keeps producers and listeners of an event from silently
+// diverging over a typo or a difference in case.
+type EventName string
+
+// Events fired on the consensus event switch. Their values match the
+// corresponding types.Event*Value constants.
+const (
+	EventNewRoundStep EventName = "NewRoundStep"
+	EventValidBlock   EventName = "ValidBlock"
+	EventVote         EventName = "Vote"
+)
+
+// String implements the fmt.Stringer interface.
+func (name EventName) String() string { return string(name) }
+
+func (evsw *eventSwitch) AddListenerForEventName(listenerID string, event EventName, cb EventCallback) error {
+	return evsw.AddListenerForEvent(listenerID, string(event), cb)
+}
+
+func (evsw *eventSwitch) FireEventName(ctx context.Context, event EventName, data EventData) error {
+	return evsw.FireEvent(ctx, string(event), data)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestEventName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var named, raw int
+	require.NoError(t, evsw.AddListenerForEventName("named", EventVote,
+		func(context.Context, EventData) error {
+			named++
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("raw", "Vote",
+		func(context.Context, EventData) error {
+			raw++
+			return nil
+		}))
+
+	evsw.FireEventName(ctx, EventVote, nil)
+	evsw.FireEvent(ctx, EventVote.String(), nil)
+	assert.Equal(t, 2, named)
+	assert.Equal(t, 2, raw)
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrInvalidCount is returned by AddNTimesListener if the number of
+// deliveries is not positive.
+var ErrInvalidCount = errors.New("number of deliveries must be positive")
+
+// nTimesListener counts the deliveries left to a listener added with
+// AddNTimesListener.
+type nTimesListener struct {
+	evsw              *eventSwitch
+	listenerID, event string
+	cb                EventCallback
+
+	remaining int64 // atomic
+}
+
+func (nl *nTimesListener) fire(ctx context.Context, data EventData) error {
+	left := atomic.AddInt64(&nl.remaining, -1)
+	if left < 0 {
+		// a concurrent fire made the last delivery
+		return errEventDropped
+	}
+	if left == 0 {
+		// Callbacks run without the locks of the switch held, so the
+		// subscription can be removed from within its own callback.
+		defer nl.evsw.RemoveListenerForEvent(nl.event, nl.listenerID)
+	}
+	return nl.cb(ctx, data)
+}
+
+func (evsw *eventSwitch) AddNTimesListener(listenerID, event string, n int, cb EventCallback) error {
+	if cb == nil {
+		return ErrNilCallback
+	}
+	if n <= 0 {
+		return ErrInvalidCount
+	}
+	nl := &nTimesListener{evsw: evsw, listenerID: listenerID, event: event, cb: cb, remaining: int64(n)}
+	return evsw.addListener(listenerID, event, nl.fire, subState{})
+}
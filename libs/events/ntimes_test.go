@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddNTimesListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received []EventData
+	require.NoError(t, evsw.AddNTimesListener("sampler", "event", 3,
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("sampler", "other",
+		func(context.Context, EventData) error { return nil }))
+
+	for i := 1; i <= 5; i++ {
+		evsw.FireEvent(ctx, "event", i)
+	}
+	assert.Equal(t, []EventData{1, 2, 3}, received)
+	assert.Empty(t, evsw.Listeners("event"))
+	assert.Equal(t, []string{"sampler"}, evsw.Listeners("other"),
+		"only the subscription to the event is removed")
+
+	noop := func(context.Context, EventData) error { return nil }
+	assert.ErrorIs(t, evsw.AddNTimesListener("sampler", "event", 0, noop), ErrInvalidCount)
+	assert.ErrorIs(t, evsw.AddNTimesListener("sampler", "event", 1, nil), ErrNilCallback)
+}
+
+func TestAddNTimesListenerConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	const n = 10
+	var delivered int64
+	require.NoError(t, evsw.AddNTimesListener("sampler", "event", n,
+		func(context.Context, EventData) error {
+			atomic.AddInt64(&delivered, 1)
+			return nil
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				evsw.FireEvent(ctx, "event", nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(n), atomic.LoadInt64(&delivered))
+	assert.Empty(t, evsw.Listeners("event"))
+}
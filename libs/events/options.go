@@ -0,0 +1,255 @@
+package events
+
+import "time"
+
+// Option configures an EventSwitch created by NewEventSwitch.
+type Option func(*eventSwitch)
+
+// FireInterceptor is consulted at the start of every fire. If proceed is
+// false the fire is dropped; otherwise it is delayed by delay before being
+// delivered to the listeners.
+type FireInterceptor func(event string, data EventData) (proceed bool, delay time.Duration)
+
+// WithFireInterceptor installs an interceptor that can drop or delay fires,
+// which lets tests inject event loss and latency deterministically. Without
+// an interceptor fires are delivered immediately.
+func WithFireInterceptor(interceptor FireInterceptor) Option {
+	return func(evsw *eventSwitch) {
+		evsw.interceptor = interceptor
+	}
+}
+
+// WithErrorRateWindow enables tracking the error rate of each listener over
+// its last window invocations, as reported by ListenerErrorRate. Tracking is
+// disabled by default.
+func WithErrorRateWindow(window int) Option {
+	return func(evsw *eventSwitch) {
+		if window > 0 {
+			evsw.errorRates = newErrorRates(window)
+		}
+	}
+}
+
+// WithLatencyWindow enables tracking the latency of the last window fires
+// of each event, as reported by LatencyPercentiles. The memory used is
+// proportional to window for each event fired, or for each of the events
+// WithMaxCachedEvents retains. Tracking is disabled by default.
+func WithLatencyWindow(window int) Option {
+	return func(evsw *eventSwitch) {
+		if window > 0 {
+			evsw.latencies = newFireLatencies(window)
+		}
+	}
+}
+
+// WithCircuitBreaker gives every listener a circuit breaker: once a
+// listener's callback has returned an error failures times in a row, it is
+// no longer invoked until cooldown has elapsed. A single trial invocation is
+// then allowed, which closes the breaker again if it succeeds and reopens it
+// otherwise. Fires skipped by an open breaker count as skipped deliveries.
+// The state of the breakers is reported by Stats.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		if failures > 0 {
+			evsw.breakers = newBreakers(failures, cooldown)
+		}
+	}
+}
+
+// WithSlowCallbackThreshold makes the switch log every callback that runs
+// for longer than threshold, along with its listener ID and event. A zero
+// threshold, the default, disables the check.
+func WithSlowCallbackThreshold(threshold time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		evsw.slowCallback = threshold
+	}
+}
+
+// WithFailoverTimeout bounds how long each member of a failover group, see
+// AddFailoverListener, may take to handle a fire before the next member is
+// tried. The member is then given up on: its context is cancelled and its
+// outcome ignored, so it must return promptly once its context is done. By
+// default members are not bounded.
+func WithFailoverTimeout(timeout time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		if timeout > 0 {
+			evsw.failoverTimeout = timeout
+		}
+	}
+}
+
+// WithDefaultFireTimeout gives the callbacks invoked with a context that
+// has no deadline, such as context.Background(), a context of their own
+// that expires after timeout, so that a listener stuck waiting on its
+// context cannot block the fire forever. Contexts that have a deadline are
+// left untouched. Callbacks ignoring their context are not interrupted. By
+// default no timeout is applied.
+func WithDefaultFireTimeout(timeout time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		if timeout > 0 {
+			evsw.fireTimeout = timeout
+		}
+	}
+}
+
+// WithClock sets the clock the switch consults for every time-dependent
+// behavior. It defaults to the real clock.
+func WithClock(clock Clock) Option {
+	return func(evsw *eventSwitch) {
+		evsw.clock = clock
+	}
+}
+
+// WithName names the switch, to tell apart the switches of a node. Every line
+// the switch logs carries the name under the "switch" key, and Stats and
+// Report include it.
+func WithName(name string) Option {
+	return func(evsw *eventSwitch) {
+		evsw.name = name
+	}
+}
+
+// WithLogSampling sets the interval over which the switch logs similar
+// lines, such as the failures of a given listener, only once; the next line
+// written reports how many were suppressed. Zero disables sampling. The
+// default is 10 seconds. It only applies to the logs of the switch itself,
+// not to those of callbacks.
+func WithLogSampling(interval time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		evsw.logSampleInterval = interval
+	}
+}
+
+// WithLifecycleEvents makes the switch fire SwitchStarted, SwitchStopping and
+// SwitchStopped as it starts and stops.
+func WithLifecycleEvents() Option {
+	return func(evsw *eventSwitch) {
+		evsw.lifecycleEvents = true
+	}
+}
+
+// WithPanicEvents makes the switch recover from panics in callbacks. A
+// recovered panic is logged, reported as an error of the callback and fired
+// as CallbackPanic. Without it, a panicking callback crashes the fire like
+// any other panic.
+func WithPanicEvents() Option {
+	return func(evsw *eventSwitch) {
+		evsw.panicEvents = true
+	}
+}
+
+// WithDeadlockDetection makes the switch detect callbacks calling a method
+// that waits for them to return, such as RemoveListenerWait of their own
+// listener or Shutdown. Instead of hanging, the method logs a stack trace
+// and returns without waiting. Tracking the callbacks is costly, so the
+// option is meant for development.
+func WithDeadlockDetection() Option {
+	return func(evsw *eventSwitch) {
+		evsw.deadlocks = newDeadlockDetector()
+	}
+}
+
+// WithCallerInfo makes the switch record the file:line of the code that
+// fires each event. It is logged at debug level and retained for the most
+// recent fires, as reported by Stats. Looking up the caller is costly, so
+// it is meant for debugging only.
+func WithCallerInfo() Option {
+	return func(evsw *eventSwitch) {
+		evsw.callers = &recentFires{}
+	}
+}
+
+// WithEverFired makes the switch record the events fired, as reported by
+// EverFired. Every fire then takes a lock to record its event, which is why
+// the option is off by default.
+func WithEverFired() Option {
+	return func(evsw *eventSwitch) {
+		evsw.fired = &firedEvents{}
+	}
+}
+
+// WithPayloadSizer sets the function measuring the size of event data
+// against the limits set with SetMaxPayloadSize, typically the length of the
+// data as serialized for downstream consumers. Data is only measured for
+// events that have a limit.
+func WithPayloadSizer(sizer PayloadSizer) Option {
+	return func(evsw *eventSwitch) {
+		evsw.sizer = sizer
+	}
+}
+
+// WithWorkerPool sets the number of workers delivering the fires of
+// FireEventNonBlocking and the number of fires that may wait for them. With
+// more than one worker, fires queued one after the other may be delivered
+// concurrently and out of order. The default is a single worker and a
+// queue of 256 fires.
+func WithWorkerPool(workers, queueSize int) Option {
+	return func(evsw *eventSwitch) {
+		if workers > 0 && queueSize >= 0 {
+			evsw.pool = newWorkerPool(workers, queueSize)
+		}
+	}
+}
+
+// WithRetries makes the switch retry the callbacks failing to handle a fire
+// of FireEventNonBlocking, in the background, until they succeed or have
+// been invoked attempts times in all. The first retry happens after backoff,
+// and every next one after twice as long as the previous. The deliveries
+// that failed every attempt, or that were still waiting for a retry when the
+// switch stopped, are kept for DeadLetters. Synchronous fires are not
+// retried.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(evsw *eventSwitch) {
+		if attempts > 0 && backoff > 0 {
+			evsw.retries = newRetries(attempts, backoff)
+		}
+	}
+}
+
+// WithInheritContext makes the fires of FireEventNonBlocking, and the
+// background deliveries of FireEventAwait, deliver with the context they were
+// fired with, so that they are abandoned, like those of FireEvent, once it is
+// done: a fire whose context is done before a worker picks it up reaches no
+// listener. By default, asynchronous deliveries only
+// end with the switch. Batching listeners always use the context of the
+// switch, since their batches span several fires.
+func WithInheritContext() Option {
+	return func(evsw *eventSwitch) {
+		evsw.inheritContext = true
+	}
+}
+
+// WithMaxCachedEvents bounds the number of events whose data the switch
+// retains for CompareAndFire. Once more than max events are cached, the
+// least recently used one is evicted and compares as if it had never been
+// fired, i.e. against nil. The latencies tracked with WithLatencyWindow are
+// bounded the same way, an evicted event reporting no latencies until it is
+// fired again, and so are the events recorded with WithEverFired, an evicted
+// event reporting false from EverFired until it is fired again. This keeps the memory use of switches firing many one-off
+// event names in check; by default the cache is unbounded.
+func WithMaxCachedEvents(max int) Option {
+	return func(evsw *eventSwitch) {
+		if max > 0 {
+			evsw.lastValues.values.max = max
+		}
+	}
+}
+
+// WithPersister sets the Persister recording the fires of events with
+// durable listeners, see AddDurableListener. Start recovers the number of
+// the last fire persisted and, if p is a Replayer, redelivers the fires not
+// acknowledged before returning; it fails if p cannot read them.
+func WithPersister(p Persister) Option {
+	return func(evsw *eventSwitch) {
+		evsw.persister = p
+	}
+}
+
+// WithClonePerListener makes the switch deliver a separate clone of Clonable
+// event data to each listener. Data that does not implement Clonable is
+// still shared by all listeners, which must then treat it as read-only.
+func WithClonePerListener() Option {
+	return func(evsw *eventSwitch) {
+		evsw.clonePerListener = true
+	}
+}
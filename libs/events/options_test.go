@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestWithFireInterceptor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const delay = 20 * time.Millisecond
+	evsw := NewEventSwitch(log.TestingLogger(), WithFireInterceptor(
+		func(event string, data EventData) (bool, time.Duration) {
+			switch event {
+			case "dropped":
+				return false, 0
+			case "delayed":
+				return true, delay
+			default:
+				return true, 0
+			}
+		}))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := map[string]int{}
+	for _, event := range []string{"dropped", "delayed", "normal"} {
+		event := event
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(context.Context, EventData) error {
+				received[event]++
+				return nil
+			}))
+	}
+
+	evsw.FireEvent(ctx, "dropped", nil)
+	evsw.FireEvent(ctx, "normal", nil)
+	start := time.Now()
+	evsw.FireEvent(ctx, "delayed", nil)
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+	assert.Equal(t, map[string]int{"delayed": 1, "normal": 1}, received)
+
+	// a delayed fire is abandoned when its context is done
+	fireCtx, fireCancel := context.WithCancel(ctx)
+	fireCancel()
+	evsw.FireEvent(fireCtx, "delayed", nil)
+	assert.Equal(t, 1, received["delayed"])
+}
+
+func TestWithFireInterceptorClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithFireInterceptor(
+		func(string, EventData) (bool, time.Duration) { return true, time.Hour }))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := make(chan EventData, 1)
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			received <- data
+			return nil
+		}))
+
+	// the lag monitor holds one timer, the delayed fire another
+	go evsw.FireEvent(ctx, "event", "data")
+	clock.waitForTimers(t, 2)
+	assert.Empty(t, received)
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, "data", <-received)
+}
+
+// recordingLogger records the messages logged at info and error level.
+type recordingLogger struct {
+	log.Logger
+
+	mtx   sync.Mutex
+	infos []string
+}
+
+func (l *recordingLogger) Info(msg string, keyVals ...interface{}) {
+	l.mtx.Lock()
+	l.infos = append(l.infos, fmt.Sprint(msg, keyVals))
+	l.mtx.Unlock()
+}
+
+func (l *recordingLogger) Error(msg string, keyVals ...interface{}) {
+	l.Info(msg, keyVals...)
+}
+
+func (l *recordingLogger) messages() []string {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return append([]string(nil), l.infos...)
+}
+
+func TestWithSlowCallbackThreshold(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	logger := &recordingLogger{Logger: log.NewNopLogger()}
+	evsw := NewEventSwitch(logger, WithClock(clock), WithSlowCallbackThreshold(100*time.Millisecond))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			clock.Advance(data.(time.Duration))
+			return nil
+		}))
+
+	evsw.FireEvent(ctx, "event", 100*time.Millisecond)
+	for _, msg := range logger.messages() {
+		assert.NotContains(t, msg, "slow event callback")
+	}
+
+	evsw.FireEvent(ctx, "event", 150*time.Millisecond)
+	var slow []string
+	for _, msg := range logger.messages() {
+		if strings.Contains(msg, "slow event callback") {
+			slow = append(slow, msg)
+		}
+	}
+	require.Len(t, slow, 1)
+	assert.Contains(t, slow[0], "listener listener event event duration 150ms")
+}
+
+func TestWithDefaultFireTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const timeout = 50 * time.Millisecond
+	evsw := NewEventSwitch(log.TestingLogger(), WithDefaultFireTimeout(timeout))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var cbErr error
+	require.NoError(t, evsw.AddListenerForEvent("stuck", "event",
+		func(ctx context.Context, _ EventData) error {
+			select {
+			case <-ctx.Done():
+				cbErr = ctx.Err()
+			case <-time.After(10 * time.Second):
+			}
+			return nil
+		}))
+
+	start := time.Now()
+	evsw.FireEvent(context.Background(), "event", nil)
+	assert.ErrorIs(t, cbErr, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), timeout)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestWithDefaultFireTimeoutKeepsDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithDefaultFireTimeout(time.Millisecond))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var deadline time.Time
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(ctx context.Context, _ EventData) error {
+			deadline, _ = ctx.Deadline()
+			return nil
+		}))
+
+	fireCtx, fireCancel := context.WithTimeout(ctx, time.Hour)
+	defer fireCancel()
+	want, _ := fireCtx.Deadline()
+	evsw.FireEvent(fireCtx, "event", nil)
+	assert.Equal(t, want, deadline)
+}
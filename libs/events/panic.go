@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"fmt"
+)
+
+// CallbackPanic is fired by a switch created with WithPanicEvents when a
+// callback panics. The event data is a CallbackPanicData. Panics in the
+// callbacks of CallbackPanic itself are recovered and logged but not fired
+// again.
+const CallbackPanic = "events/callback_panic"
+
+// CallbackPanicData is the event data of CallbackPanic.
+type CallbackPanicData struct {
+	ListenerID string
+	Event      string
+	// Value is the value the callback panicked with.
+	Value interface{}
+}
+
+// ErrCallbackPanicked is returned in place of the error of a callback that
+// panicked, as recorded by the error statistics and returned by
+// FireEventParallel.
+type ErrCallbackPanicked struct {
+	Value interface{}
+}
+
+// Error implements the error interface.
+func (e ErrCallbackPanicked) Error() string {
+	return fmt.Sprintf("callback panicked: %v", e.Value)
+}
+
+// callRecovering runs the callback of lc, turning a panic into an
+// ErrCallbackPanicked.
+func (evsw *eventSwitch) callRecovering(ctx context.Context, lc listenerCallback, data EventData) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			evsw.logger.Error("event callback panicked",
+				"listener", lc.listenerID, "event", lc.event, "panic", r)
+			err = ErrCallbackPanicked{Value: r}
+
+			if lc.event != CallbackPanic {
+				_ = evsw.FireEvent(ctx, CallbackPanic, CallbackPanicData{
+					ListenerID: lc.listenerID,
+					Event:      lc.event,
+					Value:      r,
+				})
+			}
+		}
+	}()
+	return lc.cb(ctx, data)
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestWithPanicEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithPanicEvents())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var panics []CallbackPanicData
+	require.NoError(t, evsw.AddListenerForEvent("monitor", CallbackPanic,
+		func(_ context.Context, data EventData) error {
+			panics = append(panics, data.(CallbackPanicData))
+			panic("monitor broke too")
+		}))
+	require.NoError(t, evsw.AddListenerForEvent("broken", "event",
+		func(context.Context, EventData) error {
+			panic("boom")
+		}))
+
+	err := evsw.FireEventParallel(ctx, "event", nil)
+	require.Equal(t, ErrCallbackPanicked{Value: "boom"}, err)
+
+	// the panic of the monitor itself is not fired again
+	require.Len(t, panics, 1)
+	assert.Equal(t, CallbackPanicData{ListenerID: "broken", Event: "event", Value: "boom"}, panics[0])
+	assert.EqualValues(t, 2, evsw.Report().CallbackErrors)
+}
+
+func TestPanicsPropagateByDefault(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.AddListenerForEvent("broken", "event",
+		func(context.Context, EventData) error {
+			panic("boom")
+		}))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		evsw.FireEvent(context.Background(), "event", nil)
+	})
+}
@@ -0,0 +1,46 @@
+package events
+
+import "sync"
+
+// PayloadSizer returns the size in bytes of event data, see
+// WithPayloadSizer. Data it fails to measure is treated as oversized.
+type PayloadSizer func(data EventData) (int, error)
+
+// payloadLimits holds the maximum payload size of each event.
+type payloadLimits struct {
+	mtx    sync.RWMutex
+	limits map[string]int
+}
+
+func (evsw *eventSwitch) SetMaxPayloadSize(event string, bytes int) {
+	p := &evsw.maxPayload
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if bytes <= 0 {
+		delete(p.limits, event)
+		return
+	}
+	if p.limits == nil {
+		p.limits = make(map[string]int)
+	}
+	p.limits[event] = bytes
+}
+
+// isOversized reports whether data exceeds the payload limit of event.
+func (evsw *eventSwitch) isOversized(event string, data EventData) bool {
+	p := &evsw.maxPayload
+	p.mtx.RLock()
+	limit, ok := p.limits[event]
+	p.mtx.RUnlock()
+	if !ok {
+		return false
+	}
+
+	size, err := evsw.sizer(data)
+	if err != nil {
+		evsw.logger.Error("failed to measure event data", "event", event, "err", err)
+		return true
+	}
+	return size > limit
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func jsonSize(data EventData) (int, error) {
+	bz, err := json.Marshal(data)
+	return len(bz), err
+}
+
+func TestSetMaxPayloadSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithPayloadSizer(jsonSize))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received []EventData
+	for _, event := range []string{"limited", "unlimited"} {
+		require.NoError(t, evsw.AddListenerForEvent("listener", event,
+			func(_ context.Context, data EventData) error {
+				received = append(received, data)
+				return nil
+			}))
+	}
+	evsw.SetMaxPayloadSize("limited", 5)
+
+	evsw.FireEvent(ctx, "limited", "abc")     // 5 bytes
+	evsw.FireEvent(ctx, "limited", "abcd")    // 6 bytes
+	evsw.FireEvent(ctx, "limited", func() {}) // cannot be measured
+	evsw.FireEvent(ctx, "unlimited", "abcd")
+	assert.Equal(t, []EventData{"abc", "abcd"}, received)
+	assert.EqualValues(t, 2, evsw.Report().OversizedFires)
+
+	evsw.SetMaxPayloadSize("limited", 0)
+	evsw.FireEvent(ctx, "limited", "abcd")
+	assert.Len(t, received, 3)
+}
+
+func TestSetMaxPayloadSizeWithoutSizer(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+
+	var received int
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			received++
+			return nil
+		}))
+	evsw.SetMaxPayloadSize("event", 1)
+	evsw.FireEvent(context.Background(), "event", "too large")
+	assert.Equal(t, 1, received)
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Persister records the fires delivered to durable listeners, see
+// AddDurableListener, so that their numbering continues across restarts. A
+// Persister that is also a Replayer has the fires the durable listeners
+// missed redelivered after a restart.
+type Persister interface {
+	// Persist durably records the fire of event numbered seq before it is
+	// delivered. at is the time of the fire, as given to FireEventAt or
+	// else read from the switch Clock. If it fails, the durable listeners
+	// of event miss the fire, while the others still receive it.
+	Persist(seq uint64, event string, data EventData, at time.Time) error
+	// Recover returns the number of the last fire persisted, or zero if
+	// there is none. The switch numbers its fires from there on.
+	Recover() (uint64, error)
+}
+
+// Replayer is implemented by the Persisters that can read their records
+// back. The switch acknowledges each persisted fire once it was delivered
+// to the durable listeners, and Start redelivers the fires persisted but
+// not acknowledged before the switch stopped, e.g. because the process
+// crashed in the middle of their delivery.
+//
+// A fire may thus be delivered twice to a durable listener: once before a
+// crash, and again after the restart if the crash came before it was
+// acknowledged. Listeners that must process each fire exactly once record
+// the number of the last fire they processed along with their own state,
+// and skip the fires numbered up to it.
+type Replayer interface {
+	// Ack durably records that the fire numbered seq was delivered to its
+	// durable listeners.
+	Ack(seq uint64) error
+	// Unacked returns the fires persisted but not acknowledged, in the
+	// order they were persisted.
+	Unacked() ([]NamedEvent, error)
+}
+
+// seqKey is the context key of the sequence number of a persisted fire.
+type seqKey struct{}
+
+// SeqFromContext returns the sequence number of the persisted fire whose
+// callback received ctx, if any.
+func SeqFromContext(ctx context.Context) (uint64, bool) {
+	seq, ok := ctx.Value(seqKey{}).(uint64)
+	return seq, ok
+}
+
+func (evsw *eventSwitch) AddDurableListener(listenerID, event string, cb EventCallback) error {
+	return evsw.addListener(listenerID, event, cb, subState{durable: true})
+}
+
+// markDurable marks the subscription identified by key as durable; it is
+// called with mtx held.
+func (evsw *eventSwitch) markDurable(key subKey) {
+	if evsw.durable[key.event] == nil {
+		evsw.durable[key.event] = make(map[string]struct{})
+	}
+	evsw.durable[key.event][key.listenerID] = struct{}{}
+}
+
+// forgetDurable forgets that the subscription identified by key is durable;
+// it is called with mtx held.
+func (evsw *eventSwitch) forgetDurable(key subKey) {
+	if listeners := evsw.durable[key.event]; listeners != nil {
+		delete(listeners, key.listenerID)
+		if len(listeners) == 0 {
+			delete(evsw.durable, key.event)
+		}
+	}
+}
+
+// durableListeners returns a copy of the durable listeners of event.
+func (evsw *eventSwitch) durableListeners(event string) map[string]struct{} {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	listeners := make(map[string]struct{}, len(evsw.durable[event]))
+	for listenerID := range evsw.durable[event] {
+		listeners[listenerID] = struct{}{}
+	}
+	return listeners
+}
+
+// recoverPersisted numbers the persisted fires after the last one persisted
+// before the switch started, and returns the fires to replay if the
+// Persister is a Replayer.
+func (evsw *eventSwitch) recoverPersisted() ([]NamedEvent, error) {
+	seq, err := evsw.persister.Recover()
+	if err != nil {
+		return nil, fmt.Errorf("recovering persisted events: %w", err)
+	}
+
+	evsw.persistMtx.Lock()
+	evsw.persistSeq = seq
+	evsw.persistMtx.Unlock()
+
+	r, ok := evsw.persister.(Replayer)
+	if !ok {
+		return nil, nil
+	}
+	unacked, err := r.Unacked()
+	if err != nil {
+		return nil, fmt.Errorf("reading unacknowledged events: %w", err)
+	}
+	return unacked, nil
+}
+
+// replay delivers the fires recovered from a Replayer to the durable
+// listeners of their event, in order, and acknowledges them. The callbacks
+// read the number and the time of the fire from their context, as they did
+// for the original delivery. A fire none of whose listeners is durable any
+// longer is acknowledged all the same.
+func (evsw *eventSwitch) replay(ctx context.Context, unacked []NamedEvent) {
+	for _, ne := range unacked {
+		durable := evsw.durableListeners(ne.Event)
+		var callbacks []listenerCallback
+		for _, lc := range evsw.callbacks(ne.Event) {
+			if _, ok := durable[lc.listenerID]; ok {
+				callbacks = append(callbacks, lc)
+			}
+		}
+
+		fireCtx := context.WithValue(ctx, seqKey{}, ne.Seq)
+		fireCtx = context.WithValue(fireCtx, fireTimeKey{}, ne.Time)
+		evsw.dispatch(fireCtx, callbacks, ne.Data)
+		if ctx.Err() != nil {
+			// The rest is replayed on the next start.
+			return
+		}
+		evsw.ack(ne.Seq)
+	}
+}
+
+// ack acknowledges the persisted fire numbered seq if the Persister is a
+// Replayer. A fire that could not be acknowledged is replayed on the next
+// start.
+func (evsw *eventSwitch) ack(seq uint64) {
+	r, ok := evsw.persister.(Replayer)
+	if !ok {
+		return
+	}
+	if err := r.Ack(seq); err != nil {
+		evsw.logger.Error("failed to acknowledge persisted event", "seq", seq, "err", err)
+	}
+}
+
+// persist persists the fire of event if it has durable listeners, and
+// returns the context to deliver it with, carrying its sequence number, and
+// the number itself. If the fire could not be persisted, it returns the
+// durable listeners, which must not receive it, and a zero number.
+func (evsw *eventSwitch) persist(
+	ctx context.Context,
+	event string,
+	data EventData,
+) (context.Context, uint64, map[string]struct{}) {
+	evsw.mtx.RLock()
+	durable := len(evsw.durable[event]) > 0
+	evsw.mtx.RUnlock()
+	if !durable {
+		return ctx, 0, nil
+	}
+
+	// Fires are numbered and persisted under a single lock, so that they
+	// are persisted in order.
+	at := evsw.fireTime(ctx)
+	evsw.persistMtx.Lock()
+	seq := evsw.persistSeq + 1
+	err := evsw.persister.Persist(seq, event, data, at)
+	if err == nil {
+		evsw.persistSeq = seq
+	}
+	evsw.persistMtx.Unlock()
+
+	if err != nil {
+		evsw.logger.Error("failed to persist event", "event", event, "seq", seq, "err", err)
+		return ctx, 0, evsw.durableListeners(event)
+	}
+	return context.WithValue(ctx, seqKey{}, seq), seq, nil
+}
@@ -0,0 +1,271 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// memPersister is a Persister keeping the fires in memory.
+type memPersister struct {
+	mtx        sync.Mutex
+	last       uint64
+	persisted  []NamedEvent
+	persistErr error
+	recoverErr error
+}
+
+func (p *memPersister) Persist(seq uint64, event string, data EventData, at time.Time) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.persistErr != nil {
+		return p.persistErr
+	}
+	p.persisted = append(p.persisted, NamedEvent{Event: event, Data: data, Seq: seq, Time: at})
+	return nil
+}
+
+func (p *memPersister) Recover() (uint64, error) {
+	return p.last, p.recoverErr
+}
+
+// memReplayer is a Replayer keeping the fires and acknowledgements in
+// memory.
+type memReplayer struct {
+	memPersister
+	unacked []NamedEvent
+	acked   []uint64
+}
+
+func (r *memReplayer) Ack(seq uint64) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.acked = append(r.acked, seq)
+	return nil
+}
+
+func (r *memReplayer) Unacked() ([]NamedEvent, error) {
+	return r.unacked, nil
+}
+
+func TestAddDurableListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	persister := &memPersister{last: 41}
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithPersister(persister))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var seqs []uint64
+	record := func(ctx context.Context, _ EventData) error {
+		seq, ok := SeqFromContext(ctx)
+		if ok {
+			seqs = append(seqs, seq)
+		}
+		return nil
+	}
+	require.NoError(t, evsw.AddDurableListener("durable", "committed", record))
+	require.NoError(t, evsw.AddListenerForEvent("plain", "committed", record))
+	require.NoError(t, evsw.AddListenerForEvent("plain", "proposed", record))
+
+	evsw.FireEvent(ctx, "committed", "block 1")
+	evsw.FireEvent(ctx, "proposed", "block 2")
+	evsw.FireEvent(ctx, "committed", "block 2")
+	// the fire time is the clock's, unless given to FireEventAt
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	evsw.FireEventAt(ctx, "committed", "block 3", at)
+
+	assert.Equal(t, []uint64{42, 42, 43, 43, 44, 44}, seqs, "every listener of a durable fire reads its number")
+	assert.Equal(t, []NamedEvent{
+		{Event: "committed", Data: "block 1", Seq: 42, Time: clock.Now()},
+		{Event: "committed", Data: "block 2", Seq: 43, Time: clock.Now()},
+		{Event: "committed", Data: "block 3", Seq: 44, Time: at},
+	}, persister.persisted)
+
+	// the event is no longer persisted once it has no durable listener
+	evsw.RemoveListener("durable")
+	seqs = nil
+	evsw.FireEvent(ctx, "committed", "block 4")
+	assert.Empty(t, seqs)
+	assert.Len(t, persister.persisted, 3)
+}
+
+func TestAddDurableListenerPersistFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := &memPersister{persistErr: errors.New("disk full")}
+	evsw := NewEventSwitch(log.TestingLogger(), WithPersister(persister))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var delivered []string
+	record := func(listenerID string) EventCallback {
+		return func(context.Context, EventData) error {
+			delivered = append(delivered, listenerID)
+			return nil
+		}
+	}
+	require.NoError(t, evsw.AddDurableListener("durable", "committed", record("durable")))
+	require.NoError(t, evsw.AddListenerForEvent("plain", "committed", record("plain")))
+
+	evsw.FireEvent(ctx, "committed", nil)
+	assert.Equal(t, []string{"plain"}, delivered, "only the durable listener misses a fire that could not be persisted")
+
+	// likewise with a single listener
+	delivered = nil
+	require.NoError(t, evsw.AddDurableListener("durable", "single", record("durable")))
+	evsw.FireEvent(ctx, "single", nil)
+	assert.Empty(t, delivered)
+}
+
+func TestAddDurableListenerReplaced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := &memPersister{}
+	evsw := NewEventSwitch(log.TestingLogger(), WithPersister(persister))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var seqs []uint64
+	record := func(ctx context.Context, _ EventData) error {
+		seq, ok := SeqFromContext(ctx)
+		if ok {
+			seqs = append(seqs, seq)
+		}
+		return nil
+	}
+	require.NoError(t, evsw.AddDurableListener("durable", "committed", record))
+	evsw.FireEvent(ctx, "committed", "block 1")
+
+	// replacing the callback keeps the subscription durable
+	require.NoError(t, evsw.ReplaceListenerCallback("durable", "committed", record))
+	evsw.FireEvent(ctx, "committed", "block 2")
+	assert.Equal(t, []uint64{1, 2}, seqs)
+	assert.Len(t, persister.persisted, 2)
+
+	// subscribing again with AddListenerForEvent does not
+	require.NoError(t, evsw.AddListenerForEvent("durable", "committed", record))
+	evsw.FireEvent(ctx, "committed", "block 3")
+	assert.Equal(t, []uint64{1, 2}, seqs)
+	assert.Len(t, persister.persisted, 2)
+}
+
+func TestAddDurableListenerWithRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	persister := &memPersister{}
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithPersister(persister),
+		WithRetries(3, time.Second), WithLatencyWindow(10))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	seqs := make(chan uint64, 1)
+	require.NoError(t, evsw.AddDurableListener("durable", "committed",
+		func(ctx context.Context, _ EventData) error {
+			clock.Advance(time.Millisecond)
+			seq, _ := SeqFromContext(ctx)
+			seqs <- seq
+			return nil
+		}))
+
+	// queued fires are persisted and timed when retried deliveries are on
+	require.True(t, evsw.FireEventNonBlocking(ctx, "committed", "block 1"))
+	select {
+	case seq := <-seqs:
+		assert.Equal(t, uint64(1), seq)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the durable listener was not invoked")
+	}
+	require.Eventually(t, func() bool {
+		p50, _, _ := evsw.LatencyPercentiles("committed")
+		return p50 == time.Millisecond
+	}, 5*time.Second, time.Millisecond)
+
+	persister.mtx.Lock()
+	defer persister.mtx.Unlock()
+	assert.Equal(t, []NamedEvent{{Event: "committed", Data: "block 1", Seq: 1, Time: time.Unix(0, 0)}}, persister.persisted)
+}
+
+func TestWithPersisterRecoverFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCorrupt := errors.New("corrupt")
+	evsw := NewEventSwitch(log.TestingLogger(), WithPersister(&memPersister{recoverErr: errCorrupt}))
+	require.ErrorIs(t, evsw.Start(ctx), errCorrupt)
+}
+
+func TestSeqFromContext(t *testing.T) {
+	_, ok := SeqFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestReplayer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	persister := &memReplayer{
+		memPersister: memPersister{last: 2},
+		unacked:      []NamedEvent{{Event: "committed", Data: "block 2", Seq: 2, Time: at}},
+	}
+	evsw := NewEventSwitch(log.TestingLogger(), WithPersister(persister))
+
+	type receipt struct {
+		listenerID string
+		data       EventData
+		seq        uint64
+	}
+	var (
+		receipts []receipt
+		times    []time.Time
+	)
+	record := func(listenerID string) EventCallback {
+		return func(ctx context.Context, data EventData) error {
+			seq, _ := SeqFromContext(ctx)
+			receipts = append(receipts, receipt{listenerID, data, seq})
+			if at, ok := FireTimeFromContext(ctx); ok {
+				times = append(times, at)
+			}
+			return nil
+		}
+	}
+	require.NoError(t, evsw.AddDurableListener("durable", "committed", record("durable")))
+	require.NoError(t, evsw.AddListenerForEvent("plain", "committed", record("plain")))
+
+	// Start redelivers the unacknowledged fire to the durable listener only
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	assert.Equal(t, []receipt{{"durable", "block 2", 2}}, receipts)
+	assert.Equal(t, []time.Time{at}, times)
+	assert.Equal(t, []uint64{2}, persister.acked)
+
+	// new fires are acknowledged once delivered
+	receipts = nil
+	evsw.FireEvent(ctx, "committed", "block 3")
+	assert.Equal(t, []receipt{{"durable", "block 3", 3}, {"plain", "block 3", 3}}, receipts)
+	assert.Equal(t, []uint64{2, 3}, persister.acked)
+
+	// but not if the fire was abandoned before reaching every listener
+	fireCtx, cancelFire := context.WithCancel(ctx)
+	require.NoError(t, evsw.ReplaceListenerCallback("durable", "committed",
+		func(context.Context, EventData) error {
+			cancelFire()
+			return nil
+		}))
+	evsw.FireEvent(fireCtx, "committed", "block 4")
+	assert.Equal(t, []uint64{2, 3}, persister.acked)
+}
@@ -0,0 +1,243 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultPoolWorkers = 1
+	defaultPoolQueue   = 256
+)
+
+// queuedFire is a fire waiting for a worker, see FireEventNonBlocking.
+type queuedFire struct {
+	ctx   context.Context
+	event string
+	data  EventData
+	seq   uint64
+}
+
+// workerPool delivers queued fires from a fixed number of goroutines.
+type workerPool struct {
+	workers int
+	queue   chan queuedFire
+
+	// mtx is held for reading by enqueuers and for writing by close, so no
+	// fire is queued once queue is closed. done is closed once the workers
+	// started by start are gone; it is nil until then.
+	mtx     sync.RWMutex
+	stopped bool
+	done    chan struct{}
+
+	// dropping makes the workers drop the fires they pick up instead of
+	// delivering them; dropped counts the fires dropped so far.
+	dropping uint32 // atomic
+	dropped  int64  // atomic
+
+	// pending mirrors the contents of queue for PendingEvents; pendingMtx
+	// is held while sending to queue, so that a fire is recorded before a
+	// worker can pick it up.
+	pendingMtx sync.Mutex
+	seq        uint64
+	pending    map[uint64]NamedEvent
+}
+
+func newWorkerPool(workers, queueSize int) workerPool {
+	return workerPool{
+		workers: workers,
+		queue:   make(chan queuedFire, queueSize),
+		pending: make(map[uint64]NamedEvent),
+	}
+}
+
+// enqueue queues a fire and reports whether there was room for it.
+func (p *workerPool) enqueue(qf queuedFire, clock Clock) bool {
+	p.pendingMtx.Lock()
+	defer p.pendingMtx.Unlock()
+
+	qf.seq = p.seq + 1
+	select {
+	case p.queue <- qf:
+		p.seq++
+		p.pending[qf.seq] = NamedEvent{Event: qf.event, Data: qf.data, Seq: qf.seq, Time: clock.Now()}
+		return true
+	default:
+		return false
+	}
+}
+
+// dequeued records that a worker picked up, or stop dropped, a fire.
+func (p *workerPool) dequeued(qf queuedFire) {
+	p.pendingMtx.Lock()
+	delete(p.pending, qf.seq)
+	p.pendingMtx.Unlock()
+}
+
+// start starts the workers, which deliver the queued fires until ctx is
+// done or the queue is closed and empty.
+func (p *workerPool) start(ctx context.Context, evsw *eventSwitch) {
+	done := make(chan struct{})
+	p.mtx.Lock()
+	p.done = done
+	p.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case qf, ok := <-p.queue:
+					if !ok {
+						return
+					}
+					p.dequeued(qf)
+					if atomic.LoadUint32(&p.dropping) == 1 {
+						p.drop()
+						evsw.fires.end()
+						continue
+					}
+					evsw.deliverQueued(qf)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		p.stop(evsw)
+		close(done)
+	}()
+}
+
+// close rejects further fires. It may be called more than once.
+func (p *workerPool) close() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+}
+
+// drain rejects further fires and waits until the workers delivered those
+// still queued, or ctx is done, in which case it drops the rest. It returns
+// the number of fires dropped, and drops every queued fire at once if the
+// workers were never started.
+func (p *workerPool) drain(ctx context.Context, evsw *eventSwitch) int {
+	p.close()
+	p.mtx.RLock()
+	done := p.done
+	p.mtx.RUnlock()
+
+	if done != nil {
+		select {
+		case <-done:
+			return int(atomic.LoadInt64(&p.dropped))
+		case <-ctx.Done():
+		}
+	}
+	return p.stop(evsw)
+}
+
+// stop rejects further fires and drops those still queued, along with any
+// the workers pick up from then on. It returns the number of fires dropped
+// so far and may be called more than once.
+func (p *workerPool) stop(evsw *eventSwitch) int {
+	p.close()
+	atomic.StoreUint32(&p.dropping, 1)
+	for qf := range p.queue {
+		p.dequeued(qf)
+		p.drop()
+		evsw.fires.end()
+	}
+	return int(atomic.LoadInt64(&p.dropped))
+}
+
+// drop counts a dropped fire.
+func (p *workerPool) drop() {
+	atomic.AddInt64(&p.dropped, 1)
+}
+
+func (evsw *eventSwitch) FireEventNonBlocking(ctx context.Context, event string, data EventData) bool {
+	p := &evsw.pool
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	if p.stopped || !evsw.fires.begin() {
+		return false
+	}
+	if !p.enqueue(queuedFire{ctx: ctx, event: event, data: data}, evsw.clock) {
+		evsw.fires.end()
+		return false
+	}
+	return true
+}
+
+func (evsw *eventSwitch) PendingEvents() []NamedEvent {
+	p := &evsw.pool
+	p.pendingMtx.Lock()
+	pending := make([]NamedEvent, 0, len(p.pending))
+	for _, ne := range p.pending {
+		pending = append(pending, ne)
+	}
+	p.pendingMtx.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Seq < pending[j].Seq })
+	return pending
+}
+
+func (evsw *eventSwitch) QueueLen() int {
+	return len(evsw.pool.queue)
+}
+
+func (evsw *eventSwitch) QueueCap() int {
+	return cap(evsw.pool.queue)
+}
+
+// deliverQueued delivers a fire queued by FireEventNonBlocking. The fire
+// counts as in progress from the moment it was queued, until it is
+// delivered or dropped.
+func (evsw *eventSwitch) deliverQueued(qf queuedFire) {
+	defer evsw.fires.end()
+
+	ctx := qf.ctx
+	if !evsw.inheritContext {
+		ctx = detachContext(ctx, evsw.Context())
+	}
+	if evsw.retries == nil {
+		evsw.fire(ctx, qf.event, qf.data)
+		return
+	}
+
+	pf, err := evsw.prepareFire(ctx, qf.event, qf.data)
+	if err != nil {
+		evsw.logTransformError(qf.event, err)
+		return
+	}
+	evsw.dispatchRetrying(pf.ctx, pf.callbacks, pf.data)
+	evsw.finishFire(ctx, qf.event, qf.data, pf)
+}
+
+// detachedContext carries the values of one context and the cancellation
+// and deadline of another.
+type detachedContext struct {
+	context.Context
+	values context.Context
+}
+
+// detachContext returns a context carrying the values of ctx, such as a
+// correlation ID, but done when lifetime is rather than when ctx is.
+func detachContext(ctx, lifetime context.Context) context.Context {
+	return detachedContext{Context: lifetime, values: ctx}
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
@@ -0,0 +1,300 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireEventNonBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithWorkerPool(1, 2))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	release := make(chan struct{})
+	received := make(chan EventData, 3)
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			<-release
+			received <- data
+			return nil
+		}))
+
+	// The first fire occupies the single worker, the next two fill the
+	// queue; none of them blocks the caller.
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", 1))
+	require.Eventually(t, func() bool { return len(evsw.(*eventSwitch).pool.queue) == 0 },
+		5*time.Second, time.Millisecond)
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", 2))
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", 3))
+	assert.False(t, evsw.FireEventNonBlocking(ctx, "event", 4), "queue is full")
+
+	close(release)
+	for i := 1; i <= 3; i++ {
+		select {
+		case data := <-received:
+			assert.Equal(t, i, data)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("fire %d was not delivered", i)
+		}
+	}
+}
+
+func TestFireEventNonBlockingBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	received := make(chan EventData, 1)
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(_ context.Context, data EventData) error {
+			received <- data
+			return nil
+		}))
+
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", "queued"))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	select {
+	case data := <-received:
+		assert.Equal(t, "queued", data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued fire was not delivered once started")
+	}
+}
+
+func TestFireEventNonBlockingShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithWorkerPool(1, 4))
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return nil }))
+
+	// Never started: Shutdown drops the queued fires instead of waiting for
+	// them.
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", nil))
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", nil))
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer shutdownCancel()
+	require.Equal(t, ErrFiresDropped{Dropped: 2}, evsw.Shutdown(shutdownCtx))
+
+	assert.False(t, evsw.FireEventNonBlocking(ctx, "event", nil))
+}
+
+func TestFireEventNonBlockingShutdownDrains(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithWorkerPool(1, 8))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	release := make(chan struct{})
+	var delivered []EventData
+	require.NoError(t, evsw.AddListenerForEvent("slow", "event",
+		func(ctx context.Context, data EventData) error {
+			<-release
+			time.Sleep(10 * time.Millisecond)
+			if ctx.Err() == nil {
+				delivered = append(delivered, data)
+			}
+			return nil
+		}))
+	for i := 0; i < 5; i++ {
+		require.True(t, evsw.FireEventNonBlocking(ctx, "event", i))
+	}
+	close(release)
+
+	// the queue drains within the budget, to listeners whose context is
+	// still live
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer shutdownCancel()
+	require.NoError(t, evsw.Shutdown(shutdownCtx))
+	assert.Equal(t, []EventData{0, 1, 2, 3, 4}, delivered)
+	assert.False(t, evsw.FireEventNonBlocking(ctx, "event", 5))
+}
+
+func TestFireEventNonBlockingShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger(), WithWorkerPool(1, 8))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	require.NoError(t, evsw.AddListenerForEvent("stuck", "event",
+		func(context.Context, EventData) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}))
+	for i := 0; i < 5; i++ {
+		require.True(t, evsw.FireEventNonBlocking(ctx, "event", i))
+	}
+	<-started
+
+	// the worker is stuck on the first fire: the other four are dropped
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer shutdownCancel()
+	err := evsw.Shutdown(shutdownCtx)
+	require.Equal(t, ErrFiresDropped{Dropped: 4, Err: context.DeadlineExceeded}, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, evsw.PendingEvents())
+}
+
+// BenchmarkFireGoroutines compares the goroutines alive while slow listeners
+// process a burst of fires, when the fires are spawned with "go FireEvent"
+// and when they are handed to FireEventNonBlocking.
+func BenchmarkFireGoroutines(b *testing.B) {
+	const burst = 100
+
+	run := func(b *testing.B, fire func(ctx context.Context, evsw EventSwitch, wg *sync.WaitGroup)) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		evsw := NewEventSwitch(log.NewNopLogger(), WithWorkerPool(4, burst))
+		require.NoError(b, evsw.Start(ctx))
+		defer evsw.Wait()
+
+		var wg sync.WaitGroup
+		require.NoError(b, evsw.AddListenerForEvent("listener", "event",
+			func(context.Context, EventData) error {
+				defer wg.Done()
+				time.Sleep(10 * time.Microsecond)
+				return nil
+			}))
+
+		base := runtime.NumGoroutine()
+		peak := 0
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			wg.Add(burst)
+			for j := 0; j < burst; j++ {
+				fire(ctx, evsw, &wg)
+			}
+			if n := runtime.NumGoroutine() - base; n > peak {
+				peak = n
+			}
+			wg.Wait()
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(peak), "goroutines")
+		cancel()
+	}
+
+	b.Run("go FireEvent", func(b *testing.B) {
+		run(b, func(ctx context.Context, evsw EventSwitch, _ *sync.WaitGroup) {
+			go evsw.FireEvent(ctx, "event", nil)
+		})
+	})
+	b.Run("FireEventNonBlocking", func(b *testing.B) {
+		run(b, func(ctx context.Context, evsw EventSwitch, wg *sync.WaitGroup) {
+			if !evsw.FireEventNonBlocking(ctx, "event", nil) {
+				wg.Done()
+			}
+		})
+	})
+}
+
+func TestFireEventNonBlockingContext(t *testing.T) {
+	for _, inherit := range []bool{false, true} {
+		inherit := inherit
+		t.Run(fmt.Sprintf("inherit=%v", inherit), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var opts []Option
+			if inherit {
+				opts = append(opts, WithInheritContext())
+			}
+			evsw := NewEventSwitch(log.TestingLogger(), opts...)
+
+			type delivery struct {
+				corrID    string
+				cancelled bool
+			}
+			received := make(chan delivery, 2)
+			require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+				func(ctx context.Context, _ EventData) error {
+					corrID, _ := CorrelationIDFromContext(ctx)
+					received <- delivery{corrID: corrID, cancelled: ctx.Err() != nil}
+					return nil
+				}))
+
+			// Queue the fires before Start so that their contexts are done
+			// by the time they are delivered.
+			fireCtx, fireCancel := context.WithCancel(ContextWithCorrelationID(ctx, "req-1"))
+			require.True(t, evsw.FireEventNonBlocking(fireCtx, "event", nil))
+			fireCancel()
+			require.True(t, evsw.FireEventNonBlocking(ContextWithCorrelationID(ctx, "req-2"), "event", nil))
+
+			require.NoError(t, evsw.Start(ctx))
+			t.Cleanup(evsw.Wait)
+
+			want := []delivery{{corrID: "req-1"}, {corrID: "req-2"}}
+			if inherit {
+				// the first fire was abandoned along with its context
+				want = want[1:]
+			}
+			for _, d := range want {
+				select {
+				case got := <-received:
+					assert.Equal(t, d, got)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("%s was not delivered", d.corrID)
+				}
+			}
+			// the single worker delivers in order, so nothing else is due
+			assert.Empty(t, received)
+		})
+	}
+}
+
+func TestPendingEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.TestingLogger(), WithClock(clock), WithWorkerPool(1, 4))
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error { return nil }))
+
+	assert.Empty(t, evsw.PendingEvents())
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", 1))
+	queuedAt := clock.Now()
+	clock.Advance(time.Second)
+	require.True(t, evsw.FireEventNonBlocking(ctx, "other", 2))
+
+	want := []NamedEvent{
+		{Event: "event", Data: 1, Seq: 1, Time: queuedAt},
+		{Event: "other", Data: 2, Seq: 2, Time: queuedAt.Add(time.Second)},
+	}
+	assert.Equal(t, want, evsw.PendingEvents())
+	assert.Equal(t, 2, evsw.QueueLen())
+	assert.Equal(t, 4, evsw.QueueCap())
+	// the snapshot does not drain the queue
+	assert.Equal(t, want, evsw.PendingEvents())
+
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+	require.Eventually(t, func() bool {
+		return len(evsw.PendingEvents()) == 0 && evsw.QueueLen() == 0
+	}, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package events
+
+import "sync/atomic"
+
+// beginInvoke registers an invocation of the listener's callback and reports
+// whether it may proceed. An invocation allowed to proceed must call
+// endInvoke when it returns.
+func (evl *eventListener) beginInvoke() bool {
+	if atomic.LoadUint32(&evl.halted) == 1 {
+		return false
+	}
+	atomic.AddInt64(&evl.inFlight, 1)
+	// halt may have checked the count before it was incremented.
+	if atomic.LoadUint32(&evl.halted) == 1 {
+		evl.endInvoke()
+		return false
+	}
+	return true
+}
+
+func (evl *eventListener) endInvoke() {
+	if atomic.AddInt64(&evl.inFlight, -1) != 0 || atomic.LoadUint32(&evl.halted) == 0 {
+		return
+	}
+
+	evl.mtx.Lock()
+	if evl.idle != nil {
+		close(evl.idle)
+		evl.idle = nil
+	}
+	evl.mtx.Unlock()
+}
+
+// halt stops further invocations of the listener's callbacks, including
+// those of fires that snapshotted them before the listener was removed, and
+// returns a channel closed once those in progress have returned, or nil if
+// there are none.
+func (evl *eventListener) halt() <-chan struct{} {
+	atomic.StoreUint32(&evl.halted, 1)
+
+	evl.mtx.Lock()
+	defer evl.mtx.Unlock()
+	if atomic.LoadInt64(&evl.inFlight) == 0 {
+		return nil
+	}
+	if evl.idle == nil {
+		evl.idle = make(chan struct{})
+	}
+	return evl.idle
+}
+
+func (evsw *eventSwitch) RemoveListenerWait(listenerID string) {
+	listener := evsw.removeListener(listenerID)
+	if listener == nil {
+		return
+	}
+	idle := listener.halt()
+	if idle == nil || evsw.wouldDeadlock("RemoveListenerWait", listenerID) {
+		return
+	}
+	<-idle
+}
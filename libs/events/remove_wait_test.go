@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestRemoveListenerWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			close(started)
+			<-release
+			return nil
+		}))
+
+	go evsw.FireEvent(ctx, "event", nil)
+	<-started
+
+	removed := make(chan struct{})
+	go func() {
+		defer close(removed)
+		evsw.RemoveListenerWait("listener")
+	}()
+
+	select {
+	case <-removed:
+		t.Fatal("RemoveListenerWait returned while the callback was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, evsw.Listeners("event"))
+
+	close(release)
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RemoveListenerWait did not return once the callback did")
+	}
+
+	// no listener in progress: returns immediately
+	evsw.RemoveListenerWait("unknown")
+}
+
+func TestRemoveListenerWaitSkipsSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	// whichever listener runs first removes the other one, which the fire
+	// has already snapshotted
+	var calls int
+	for _, ids := range [][2]string{{"a", "b"}, {"b", "a"}} {
+		ids := ids
+		require.NoError(t, evsw.AddListenerForEvent(ids[0], "event",
+			func(context.Context, EventData) error {
+				calls++
+				evsw.RemoveListenerWait(ids[1])
+				return nil
+			}))
+	}
+	delivered, skipped := evsw.FireEventCounted(ctx, "event", nil)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 1, skipped)
+
+	// a listener that subscribes again is invoked again
+	require.NoError(t, evsw.AddListenerForEvent("a", "other",
+		func(context.Context, EventData) error {
+			calls++
+			return nil
+		}))
+	evsw.FireEvent(ctx, "other", nil)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPendingRetries is the number of failed deliveries that may wait for
+	// a retry at the same time; further failures are dead-lettered at once.
+	maxPendingRetries = 256
+	// maxDeadLetters is the number of failed deliveries DeadLetters retains.
+	maxDeadLetters = 256
+)
+
+// FailedEvent is a delivery of FireEventNonBlocking that failed every
+// attempt allowed by WithRetries.
+type FailedEvent struct {
+	Event      string
+	Data       EventData
+	ListenerID string
+	// LastErr is the error returned by the last attempt.
+	LastErr error
+	// Attempts is the number of times the callback was invoked.
+	Attempts int
+}
+
+// retries retries the failed deliveries of FireEventNonBlocking and keeps
+// those that failed for good.
+type retries struct {
+	attempts int
+	backoff  time.Duration
+
+	mtx     sync.Mutex
+	pending int
+	dead    []FailedEvent // oldest first
+}
+
+func newRetries(attempts int, backoff time.Duration) *retries {
+	return &retries{attempts: attempts, backoff: backoff}
+}
+
+// reserve reports whether a failed delivery may wait for a retry, in which
+// case it must call release once done.
+func (r *retries) reserve() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.pending >= maxPendingRetries {
+		return false
+	}
+	r.pending++
+	return true
+}
+
+func (r *retries) release() {
+	r.mtx.Lock()
+	r.pending--
+	r.mtx.Unlock()
+}
+
+func (r *retries) deadLetter(fe FailedEvent) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if len(r.dead) == maxDeadLetters {
+		r.dead = r.dead[1:]
+	}
+	r.dead = append(r.dead, fe)
+}
+
+func (evsw *eventSwitch) DeadLetters() []FailedEvent {
+	if evsw.retries == nil {
+		return nil
+	}
+	r := evsw.retries
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]FailedEvent(nil), r.dead...)
+}
+
+// dispatchRetrying dispatches like dispatch, handing the callbacks that fail
+// over to retry.
+func (evsw *eventSwitch) dispatchRetrying(ctx context.Context, callbacks []listenerCallback, data EventData) {
+	for _, lc := range callbacks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := evsw.invoke(ctx, lc, data)
+		switch {
+		case errors.Is(err, errEventDropped):
+		case errors.Is(err, ErrStopPropagation):
+			return
+		case err != nil:
+			evsw.retry(ctx, lc, data, err)
+		}
+	}
+}
+
+// retry invokes the callback of a failed delivery again in the background,
+// with exponential backoff, until it succeeds or runs out of attempts and
+// the delivery is dead-lettered. A retry pending when the switch stops, or
+// when ctx is done, is dead-lettered too; one whose listener unsubscribed is
+// abandoned.
+func (evsw *eventSwitch) retry(ctx context.Context, lc listenerCallback, data EventData, err error) {
+	r := evsw.retries
+	failed := FailedEvent{Event: lc.event, Data: data, ListenerID: lc.listenerID, LastErr: err, Attempts: 1}
+	if failed.Attempts >= r.attempts || !r.reserve() {
+		r.deadLetter(failed)
+		return
+	}
+	// The retries count as a fire in progress, so that Shutdown waits for
+	// them to be dead-lettered.
+	if !evsw.fires.begin() {
+		r.release()
+		r.deadLetter(failed)
+		return
+	}
+
+	lifetime := evsw.Context()
+	go func() {
+		defer evsw.fires.end()
+		defer r.release()
+
+		backoff := r.backoff
+		for {
+			timer := evsw.clock.NewTimer(backoff)
+			select {
+			case <-timer.C():
+			case <-lifetime.Done():
+				timer.Stop()
+				r.deadLetter(failed)
+				return
+			case <-ctx.Done():
+				timer.Stop()
+				r.deadLetter(failed)
+				return
+			}
+			if !evsw.isSubscribed(lc.listenerID, lc.event) {
+				return
+			}
+
+			err := evsw.invoke(ctx, lc, data)
+			if errors.Is(err, errEventDropped) {
+				return
+			}
+			failed.Attempts++
+			if err == nil || errors.Is(err, ErrStopPropagation) {
+				return
+			}
+			failed.LastErr = err
+			if failed.Attempts >= r.attempts {
+				r.deadLetter(failed)
+				return
+			}
+			backoff *= 2
+		}
+	}()
+}
+
+// isSubscribed reports whether the listener is subscribed to event.
+func (evsw *eventSwitch) isSubscribed(listenerID, event string) bool {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	eventCell := evsw.eventCells[event]
+	if eventCell == nil {
+		return false
+	}
+	eventCell.mtx.RLock()
+	defer eventCell.mtx.RUnlock()
+	_, ok := eventCell.listeners[listenerID]
+	return ok
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestWithRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.NewNopLogger(), WithClock(clock), WithRetries(3, time.Second))
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errFailed := errors.New("failed")
+	attempts := make(chan EventData, 10)
+	recovering := 0
+	require.NoError(t, evsw.AddListenerForEvent("flaky", "event",
+		func(_ context.Context, data EventData) error {
+			attempts <- data
+			if data == "recovers" {
+				recovering++
+				if recovering > 1 {
+					return nil
+				}
+			}
+			return errFailed
+		}))
+	next := func() EventData {
+		select {
+		case data := <-attempts:
+			return data
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback was not invoked")
+			return nil
+		}
+	}
+
+	// fails for good: invoked three times, after 1s and 2s of backoff
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", "fails"))
+	assert.Equal(t, "fails", next())
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		clock.waitForTimers(t, 2)
+		clock.Advance(backoff - time.Millisecond)
+		assert.Empty(t, attempts)
+		clock.Advance(time.Millisecond)
+		assert.Equal(t, "fails", next())
+	}
+	require.Eventually(t, func() bool { return len(evsw.DeadLetters()) == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []FailedEvent{{
+		Event:      "event",
+		Data:       "fails",
+		ListenerID: "flaky",
+		LastErr:    errFailed,
+		Attempts:   3,
+	}}, evsw.DeadLetters())
+
+	// succeeds on the first retry
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", "recovers"))
+	assert.Equal(t, "recovers", next())
+	clock.waitForTimers(t, 2)
+	clock.Advance(time.Second)
+	assert.Equal(t, "recovers", next())
+	assert.Len(t, evsw.DeadLetters(), 1)
+
+	// synchronous fires are not retried
+	evsw.FireEvent(ctx, "event", "sync")
+	assert.Equal(t, "sync", next())
+	clock.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, attempts)
+	assert.Len(t, evsw.DeadLetters(), 1)
+}
+
+func TestRetriesOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := newManualClock()
+	evsw := NewEventSwitch(log.NewNopLogger(), WithClock(clock), WithRetries(5, time.Hour))
+	require.NoError(t, evsw.Start(ctx))
+
+	require.NoError(t, evsw.AddListenerForEvent("failing", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	require.True(t, evsw.FireEventNonBlocking(ctx, "event", nil))
+	clock.waitForTimers(t, 2)
+
+	// the pending retry is dead-lettered rather than waited for
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer shutdownCancel()
+	require.NoError(t, evsw.Shutdown(shutdownCtx))
+	dead := evsw.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, 1, dead[0].Attempts)
+
+	assert.Nil(t, NewEventSwitch(log.NewNopLogger()).DeadLetters())
+}
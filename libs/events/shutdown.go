@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tendermint/tendermint/libs/service"
+)
+
+// fireTracker counts the fires in progress so Shutdown can wait for them.
+type fireTracker struct {
+	inFlight int64  // atomic
+	closed   uint32 // atomic
+
+	// idle receives a value whenever the last in-flight fire of a closed
+	// tracker completes.
+	idle chan struct{}
+}
+
+func newFireTracker() fireTracker {
+	return fireTracker{idle: make(chan struct{}, 1)}
+}
+
+// begin registers a new fire and reports whether it may proceed. A fire that
+// was allowed to proceed must call end when it completes.
+func (ft *fireTracker) begin() bool {
+	atomic.AddInt64(&ft.inFlight, 1)
+	if atomic.LoadUint32(&ft.closed) == 1 {
+		ft.end()
+		return false
+	}
+	return true
+}
+
+func (ft *fireTracker) end() {
+	if atomic.AddInt64(&ft.inFlight, -1) == 0 && atomic.LoadUint32(&ft.closed) == 1 {
+		select {
+		case ft.idle <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// reject rejects new fires.
+func (ft *fireTracker) reject() {
+	atomic.StoreUint32(&ft.closed, 1)
+}
+
+// close rejects new fires and waits until the fires in progress complete or
+// ctx is done.
+func (ft *fireTracker) close(ctx context.Context) error {
+	ft.reject()
+	for atomic.LoadInt64(&ft.inFlight) > 0 {
+		select {
+		case <-ft.idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// ErrFiresDropped is returned by Shutdown when fires queued by
+// FireEventNonBlocking were dropped instead of delivered.
+type ErrFiresDropped struct {
+	// Dropped is the number of fires dropped.
+	Dropped int
+	// Err is the error of the Shutdown context if it was done before the
+	// queue drained, and nil if the switch was not running.
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrFiresDropped) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%d queued fires dropped", e.Dropped)
+	}
+	return fmt.Sprintf("%d queued fires dropped: %v", e.Dropped, e.Err)
+}
+
+// Unwrap returns the error of the Shutdown context.
+func (e ErrFiresDropped) Unwrap() error {
+	return e.Err
+}
+
+// Shutdown rejects further fires from FireEventNonBlocking and lets the
+// workers deliver those still queued, then stops the switch, rejects further
+// fires and waits for the fires in progress to complete. Should ctx be done
+// before the queue drained, the rest of the queue is dropped and Shutdown
+// returns ErrFiresDropped, as it does for the fires queued on a switch that
+// was never started or has already stopped. Called from a callback with
+// WithDeadlockDetection, Shutdown drops the queue and returns
+// ErrWouldDeadlock without waiting. If ctx is done first, Shutdown returns
+// ctx.Err() and the remaining fires complete in the background. Shutdown may
+// be called more than once.
+func (evsw *eventSwitch) Shutdown(ctx context.Context) error {
+	// Waiting for the workers from a callback may never end: the callback
+	// might be running on one of them.
+	deadlock := evsw.wouldDeadlock("Shutdown", "")
+	var dropped int
+	if deadlock {
+		dropped = evsw.pool.stop(evsw)
+	} else {
+		dropped = evsw.pool.drain(ctx, evsw)
+	}
+
+	if err := evsw.Stop(); err != nil &&
+		!errors.Is(err, service.ErrAlreadyStopped) && !errors.Is(err, service.ErrNotStarted) {
+		return err
+	}
+	if deadlock {
+		evsw.fires.reject()
+		return ErrWouldDeadlock
+	}
+	err := evsw.fires.close(ctx)
+	if dropped > 0 {
+		return ErrFiresDropped{Dropped: dropped, Err: ctx.Err()}
+	}
+	return err
+}
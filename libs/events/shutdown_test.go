@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			calls++
+			if calls == 1 {
+				close(started)
+				<-release
+			}
+			return nil
+		}))
+
+	fired := make(chan struct{})
+	go func() {
+		defer close(fired)
+		evsw.FireEvent(ctx, "event", nil)
+	}()
+	<-started
+
+	// the in-flight fire outlives a short deadline
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	require.ErrorIs(t, evsw.Shutdown(shortCtx), context.DeadlineExceeded)
+	assert.False(t, evsw.IsRunning())
+
+	// new fires are rejected
+	evsw.FireEvent(ctx, "event", nil)
+	delivered, skipped := evsw.FireEventCounted(ctx, "event", nil)
+	assert.Zero(t, delivered+skipped)
+
+	close(release)
+	require.NoError(t, evsw.Shutdown(ctx))
+	<-fired
+	assert.Equal(t, 1, calls)
+
+	// Shutdown is idempotent
+	require.NoError(t, evsw.Shutdown(ctx))
+	evsw.Wait()
+}
+
+func TestShutdownNotStarted(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Shutdown(context.Background()))
+}
@@ -0,0 +1,17 @@
+package events
+
+import "context"
+
+// sourceKey is the context key of the source attached by FireEventFrom.
+type sourceKey struct{}
+
+// SourceFromContext returns the source of the fire whose callback received
+// ctx, as passed to FireEventFrom, if any.
+func SourceFromContext(ctx context.Context) (string, bool) {
+	source, ok := ctx.Value(sourceKey{}).(string)
+	return source, ok
+}
+
+func (evsw *eventSwitch) FireEventFrom(ctx context.Context, event string, data EventData, source string) error {
+	return evsw.FireEvent(context.WithValue(ctx, sourceKey{}, source), event, data)
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestFireEventFrom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var sources []string
+	require.NoError(t, evsw.AddListenerForEvent("listener", "peer/error",
+		func(ctx context.Context, _ EventData) error {
+			source, ok := SourceFromContext(ctx)
+			if !ok {
+				source = "<none>"
+			}
+			sources = append(sources, source)
+			return nil
+		}))
+
+	evsw.FireEventFrom(ctx, "peer/error", nil, "mempool")
+	evsw.FireEventFrom(ctx, "peer/error", nil, "consensus")
+	evsw.FireEvent(ctx, "peer/error", nil)
+	assert.Equal(t, []string{"mempool", "consensus", "<none>"}, sources)
+}
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the statistics collected by an EventSwitch.
+type Stats struct {
+	// Name is the name of the switch, see WithName.
+	Name string
+
+	// FanOut maps a number of listeners to the number of fires that were
+	// dispatched to exactly that many listeners. Fires of events without
+	// listeners are counted under zero.
+	FanOut map[int]uint64
+
+	// Listeners maps the ID of each listener with channel subscriptions to
+	// the state of their buffers.
+	Listeners map[string]ListenerStat
+
+	// Breakers maps the ID of each listener whose circuit breaker is not
+	// closed to the state of the breaker. It is nil unless the switch was
+	// created with WithCircuitBreaker.
+	Breakers map[string]BreakerState
+
+	// CachedEvents is the number of events whose last data is cached for
+	// CompareAndFire, and CacheEvictions the number of events evicted from
+	// the cache, see WithMaxCachedEvents.
+	CachedEvents   int
+	CacheEvictions uint64
+
+	// RecentFires lists the latest fires, oldest first, along with the code
+	// that fired them. It is nil unless the switch was created with
+	// WithCallerInfo.
+	RecentFires []FireRecord
+}
+
+// ListenerStat describes the buffers of the channel subscriptions (see
+// SubscribeChan) of a listener. Lengths, capacities and drops are summed over
+// all subscriptions of the listener; HighWater is the highest number of
+// buffered events any one of them has held.
+type ListenerStat struct {
+	BufferLen int
+	BufferCap int
+	HighWater int
+	Dropped   uint64
+}
+
+// SwitchReport summarizes the activity of an EventSwitch over its lifetime.
+type SwitchReport struct {
+	// Name is the name of the switch, see WithName.
+	Name string
+
+	// EventsFired is the number of fires dispatched to listeners, including
+	// fires of events without listeners.
+	EventsFired uint64
+	// CallbacksInvoked is the number of listener callbacks run.
+	CallbacksInvoked uint64
+	// CallbackErrors is the number of callbacks that returned an error.
+	CallbackErrors uint64
+	// Drops is the number of events discarded by channel subscriptions.
+	Drops uint64
+	// PeakInFlight is the largest number of callbacks that were running at
+	// the same time.
+	PeakInFlight int64
+	// DisabledFires is the number of fires ignored because their event was
+	// disabled.
+	DisabledFires uint64
+	// OversizedFires is the number of fires dropped because their data
+	// exceeded the maximum payload size of their event.
+	OversizedFires uint64
+}
+
+// switchStats collects the statistics of an eventSwitch.
+type switchStats struct {
+	// atomic counters
+	fired     uint64
+	invoked   uint64
+	errors    uint64
+	drops     uint64
+	inFlight  int64
+	peak      int64
+	disabled  uint64
+	oversized uint64
+
+	mtx    sync.Mutex
+	fanOut map[int]uint64
+}
+
+// recordFire records a fire dispatched to n listeners.
+func (s *switchStats) recordFire(n int) {
+	atomic.AddUint64(&s.fired, 1)
+
+	s.mtx.Lock()
+	if s.fanOut == nil {
+		s.fanOut = make(map[int]uint64)
+	}
+	s.fanOut[n]++
+	s.mtx.Unlock()
+}
+
+// startCallback records a callback starting to run.
+func (s *switchStats) startCallback() {
+	atomic.AddUint64(&s.invoked, 1)
+	n := atomic.AddInt64(&s.inFlight, 1)
+	for {
+		peak := atomic.LoadInt64(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt64(&s.peak, peak, n) {
+			return
+		}
+	}
+}
+
+// endCallback records a callback having returned err.
+func (s *switchStats) endCallback(err error) {
+	atomic.AddInt64(&s.inFlight, -1)
+	if err != nil {
+		atomic.AddUint64(&s.errors, 1)
+	}
+}
+
+// recordDisabled records a fire of a disabled event.
+func (s *switchStats) recordDisabled() {
+	atomic.AddUint64(&s.disabled, 1)
+}
+
+// recordOversized records a fire dropped for exceeding its payload limit.
+func (s *switchStats) recordOversized() {
+	atomic.AddUint64(&s.oversized, 1)
+}
+
+// recordDrop records an event discarded by a channel subscription.
+func (s *switchStats) recordDrop() {
+	atomic.AddUint64(&s.drops, 1)
+}
+
+func (s *switchStats) report() SwitchReport {
+	return SwitchReport{
+		EventsFired:      atomic.LoadUint64(&s.fired),
+		CallbacksInvoked: atomic.LoadUint64(&s.invoked),
+		CallbackErrors:   atomic.LoadUint64(&s.errors),
+		Drops:            atomic.LoadUint64(&s.drops),
+		PeakInFlight:     atomic.LoadInt64(&s.peak),
+		DisabledFires:    atomic.LoadUint64(&s.disabled),
+		OversizedFires:   atomic.LoadUint64(&s.oversized),
+	}
+}
+
+func (s *switchStats) snapshot() Stats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	fanOut := make(map[int]uint64, len(s.fanOut))
+	for n, count := range s.fanOut {
+		fanOut[n] = count
+	}
+	return Stats{FanOut: fanOut}
+}
+
+func (evsw *eventSwitch) Stats() Stats {
+	stats := evsw.stats.snapshot()
+	stats.Name = evsw.name
+	stats.Listeners = evsw.listenerStats()
+	stats.CachedEvents, stats.CacheEvictions = evsw.lastValues.cacheStats()
+	if evsw.breakers != nil {
+		stats.Breakers = evsw.breakers.states(evsw.clock.Now())
+	}
+	if evsw.callers != nil {
+		stats.RecentFires = evsw.callers.records()
+	}
+	return stats
+}
+
+func (evsw *eventSwitch) listenerStats() map[string]ListenerStat {
+	evsw.mtx.RLock()
+	defer evsw.mtx.RUnlock()
+
+	listeners := make(map[string]ListenerStat)
+	for key, sub := range evsw.chanSubs {
+		stat := listeners[key.listenerID]
+		stat.BufferLen += len(sub.ch)
+		stat.BufferCap += cap(sub.ch)
+		if highWater := int(atomic.LoadInt64(&sub.highWater)); highWater > stat.HighWater {
+			stat.HighWater = highWater
+		}
+		stat.Dropped += sub.Dropped()
+		listeners[key.listenerID] = stat
+	}
+	return listeners
+}
+
+func (evsw *eventSwitch) Report() SwitchReport {
+	report := evsw.stats.report()
+	report.Name = evsw.name
+	return report
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestStatsFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	assert.Empty(t, evsw.Stats().FanOut)
+
+	noop := func(context.Context, EventData) error { return nil }
+	require.NoError(t, evsw.AddListenerForEvent("listener1", "event1", noop))
+	require.NoError(t, evsw.AddListenerForEvent("listener1", "event2", noop))
+	require.NoError(t, evsw.AddListenerForEvent("listener2", "event2", noop))
+	require.NoError(t, evsw.AddListenerForEvent("listener3", "event2", noop))
+
+	evsw.FireEvent(ctx, "event1", nil)
+	evsw.FireEvent(ctx, "event2", nil)
+	evsw.FireEvent(ctx, "event2", nil)
+	evsw.FireEvent(ctx, "nothing", nil)
+
+	assert.Equal(t, map[int]uint64{0: 1, 1: 1, 3: 2}, evsw.Stats().FanOut)
+}
+
+func TestReport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	require.NoError(t, evsw.AddListenerForEvent("ok", "event",
+		func(context.Context, EventData) error { return nil }))
+	require.NoError(t, evsw.AddListenerForEvent("failing", "event",
+		func(context.Context, EventData) error { return errors.New("failed") }))
+	_, err := evsw.SubscribeChan("chan", "event", ChanOptions{BufferSize: 1, OnFull: DropNewest})
+	require.NoError(t, err)
+
+	// two callbacks blocked on the same channel are in flight together
+	release := make(chan struct{})
+	for _, listenerID := range []string{"blocked1", "blocked2"} {
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "parallel",
+			func(context.Context, EventData) error {
+				<-release
+				return nil
+			}))
+	}
+	go func() {
+		for atomic.LoadInt64(&evsw.(*eventSwitch).stats.inFlight) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+	}()
+
+	evsw.FireEvent(ctx, "event", 1)
+	evsw.FireEvent(ctx, "event", 2)
+	evsw.FireEvent(ctx, "nothing", nil)
+	require.NoError(t, evsw.FireEventParallel(ctx, "parallel", nil))
+
+	require.NoError(t, evsw.Stop())
+	evsw.Wait()
+
+	assert.Equal(t, SwitchReport{
+		EventsFired:      4,
+		CallbacksInvoked: 8,
+		CallbackErrors:   2,
+		Drops:            1,
+		PeakInFlight:     2,
+	}, evsw.Report())
+}
+
+func TestStatsListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	require.NoError(t, evsw.AddListenerForEvent("callback", "event1",
+		func(context.Context, EventData) error { return nil }))
+	ch1, err := evsw.SubscribeChan("chan", "event1", ChanOptions{BufferSize: 2, OnFull: DropNewest})
+	require.NoError(t, err)
+	_, err = evsw.SubscribeChan("chan", "event2", ChanOptions{BufferSize: 3, OnFull: DropOldest})
+	require.NoError(t, err)
+
+	evsw.FireEvent(ctx, "event1", 1)
+	evsw.FireEvent(ctx, "event1", 2)
+	evsw.FireEvent(ctx, "event1", 3)
+	evsw.FireEvent(ctx, "event2", 1)
+	<-ch1
+	<-ch1
+
+	assert.Equal(t, map[string]ListenerStat{
+		"chan": {BufferLen: 1, BufferCap: 5, HighWater: 2, Dropped: 1},
+	}, evsw.Stats().Listeners)
+
+	evsw.RemoveListener("chan")
+	assert.Empty(t, evsw.Stats().Listeners)
+}
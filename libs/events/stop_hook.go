@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// StopHook tears down a listener when the switch stops, see
+// AddListenerWithStopPriority.
+type StopHook func(ctx context.Context)
+
+// stopHook is a StopHook along with the order it runs in.
+type stopHook struct {
+	listenerID string
+	priority   int
+	seq        uint64
+	fn         StopHook
+}
+
+func (evsw *eventSwitch) AddListenerWithStopPriority(
+	listenerID, event string,
+	cb EventCallback,
+	priority int,
+	onStop StopHook,
+) error {
+	if onStop == nil {
+		return ErrNilCallback
+	}
+	if err := evsw.addListener(listenerID, event, cb, subState{}); err != nil {
+		return err
+	}
+
+	evsw.mtx.RLock()
+	listener := evsw.listeners[listenerID]
+	evsw.mtx.RUnlock()
+	if listener == nil {
+		// removed in the meantime
+		return nil
+	}
+
+	listener.mtx.Lock()
+	listener.stopHook = &stopHook{
+		listenerID: listenerID,
+		priority:   priority,
+		seq:        atomic.AddUint64(&evsw.stopSeq, 1),
+		fn:         onStop,
+	}
+	listener.mtx.Unlock()
+	return nil
+}
+
+// runStopHooks runs the stop hooks of the listeners in order of increasing
+// priority, the most recently registered first among equal priorities.
+func (evsw *eventSwitch) runStopHooks(ctx context.Context) {
+	evsw.mtx.RLock()
+	var hooks []*stopHook
+	for _, listener := range evsw.listeners {
+		listener.mtx.RLock()
+		if listener.stopHook != nil {
+			hooks = append(hooks, listener.stopHook)
+		}
+		listener.mtx.RUnlock()
+	}
+	evsw.mtx.RUnlock()
+
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority < hooks[j].priority
+		}
+		return hooks[i].seq > hooks[j].seq
+	})
+	for _, hook := range hooks {
+		evsw.runStopHook(ctx, hook)
+	}
+}
+
+func (evsw *eventSwitch) runStopHook(ctx context.Context, hook *stopHook) {
+	defer func() {
+		if r := recover(); r != nil {
+			evsw.logger.Error("listener stop hook panicked",
+				"listener", hook.listenerID, "panic", fmt.Sprint(r))
+		}
+	}()
+	hook.fn(ctx)
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddListenerWithStopPriority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+
+	var (
+		mtx sync.Mutex
+		ran []string
+	)
+	hook := func(name string) StopHook {
+		return func(ctx context.Context) {
+			assert.NoError(t, ctx.Err(), "the switch context must still be live")
+			mtx.Lock()
+			ran = append(ran, name)
+			mtx.Unlock()
+		}
+	}
+	noop := func(context.Context, EventData) error { return nil }
+
+	require.NoError(t, evsw.AddListenerWithStopPriority("flusher", "event", noop, 10, hook("flusher")))
+	require.NoError(t, evsw.AddListenerWithStopPriority("writer", "event", noop, 0, hook("writer")))
+	require.NoError(t, evsw.AddListenerWithStopPriority("indexer", "event", noop, 0, hook("indexer")))
+	require.NoError(t, evsw.AddListenerWithStopPriority("panicking", "event", noop, 5,
+		func(context.Context) { panic("boom") }))
+	require.NoError(t, evsw.AddListenerWithStopPriority("removed", "event", noop, 0, hook("removed")))
+	evsw.RemoveListener("removed")
+
+	require.ErrorIs(t, evsw.AddListenerWithStopPriority("nil", "event", noop, 0, nil), ErrNilCallback)
+
+	// concurrent shutdowns run the hooks once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, evsw.Shutdown(context.Background()))
+		}()
+	}
+	wg.Wait()
+	evsw.Wait()
+
+	assert.Equal(t, []string{"indexer", "writer", "flusher"}, ran)
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// suspendedListeners is the set of suspended listeners. Like
+// disabledEvents, it is only consulted when it is not empty.
+type suspendedListeners struct {
+	count int32 // atomic
+
+	mtx       sync.RWMutex
+	listeners map[string]struct{}
+}
+
+func (evsw *eventSwitch) SuspendListener(listenerID string) {
+	s := &evsw.suspended
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.listeners == nil {
+		s.listeners = make(map[string]struct{})
+	}
+	if _, ok := s.listeners[listenerID]; !ok {
+		s.listeners[listenerID] = struct{}{}
+		atomic.AddInt32(&s.count, 1)
+	}
+}
+
+func (evsw *eventSwitch) ResumeListener(listenerID string) {
+	evsw.suspended.remove(listenerID)
+}
+
+func (s *suspendedListeners) remove(listenerID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.listeners[listenerID]; ok {
+		delete(s.listeners, listenerID)
+		atomic.AddInt32(&s.count, -1)
+	}
+}
+
+func (s *suspendedListeners) has(listenerID string) bool {
+	if atomic.LoadInt32(&s.count) == 0 {
+		return false
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	_, ok := s.listeners[listenerID]
+	return ok
+}
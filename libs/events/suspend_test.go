@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestSuspendListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := map[string]int{}
+	for _, listenerID := range []string{"muted", "other"} {
+		listenerID := listenerID
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "event",
+			func(context.Context, EventData) error {
+				received[listenerID]++
+				return nil
+			}))
+	}
+
+	evsw.SuspendListener("muted")
+	evsw.SuspendListener("muted")
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, map[string]int{"other": 1}, received)
+	assert.Equal(t, []string{"muted", "other"}, evsw.Listeners("event"))
+
+	evsw.ResumeListener("muted")
+	evsw.ResumeListener("muted")
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, map[string]int{"muted": 1, "other": 2}, received)
+
+	// removal resumes the listener
+	evsw.SuspendListener("muted")
+	evsw.RemoveListener("muted")
+	assert.Zero(t, evsw.(*eventSwitch).suspended.count)
+}
+
+func TestSuspendListenerWhileFiring(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received int64
+	require.NoError(t, evsw.AddListenerForEvent("listener", "event",
+		func(context.Context, EventData) error {
+			atomic.AddInt64(&received, 1)
+			return nil
+		}))
+
+	const firers = 4
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < firers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					evsw.FireEvent(ctx, "event", nil)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		evsw.SuspendListener("listener")
+		before := atomic.LoadInt64(&received)
+		time.Sleep(2 * time.Millisecond)
+		// Only the fires that got past the suspension check before it
+		// began, at most one per firer, may still be delivered.
+		assert.LessOrEqual(t, atomic.LoadInt64(&received)-before, int64(firers))
+
+		evsw.ResumeListener("listener")
+		before = atomic.LoadInt64(&received)
+		require.Eventually(t, func() bool { return atomic.LoadInt64(&received) > before },
+			5*time.Second, time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	assert.Equal(t, []string{"listener"}, evsw.Listeners("event"))
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Transformer rewrites the data of the fires of an event, see
+// AddTransformer.
+type Transformer func(data EventData) (EventData, error)
+
+// eventTransformers holds the transformers of each event. Like
+// eventMiddleware, it is only consulted when it is not empty.
+type eventTransformers struct {
+	count int32 // atomic
+
+	mtx sync.RWMutex
+	m   map[string][]Transformer
+}
+
+func (evsw *eventSwitch) AddTransformer(event string, fn Transformer) {
+	if fn == nil {
+		return
+	}
+
+	et := &evsw.transforms
+	et.mtx.Lock()
+	defer et.mtx.Unlock()
+
+	if et.m == nil {
+		et.m = make(map[string][]Transformer)
+	}
+	// Copy on write, so that fires can use the pipeline they got unlocked.
+	pipeline := make([]Transformer, len(et.m[event]), len(et.m[event])+1)
+	copy(pipeline, et.m[event])
+	et.m[event] = append(pipeline, fn)
+	atomic.AddInt32(&et.count, 1)
+}
+
+func (et *eventTransformers) get(event string) []Transformer {
+	if atomic.LoadInt32(&et.count) == 0 {
+		return nil
+	}
+
+	et.mtx.RLock()
+	defer et.mtx.RUnlock()
+	return et.m[event]
+}
+
+// transform runs data through the transformers of event and returns the
+// result, or the error of the transformer that failed, which aborts the
+// fire.
+func (evsw *eventSwitch) transform(event string, data EventData) (EventData, error) {
+	for _, fn := range evsw.transforms.get(event) {
+		var err error
+		if data, err = fn(data); err != nil {
+			return nil, fmt.Errorf("transforming %s: %w", event, err)
+		}
+	}
+	return data, nil
+}
+
+// logTransformError logs the error of a fire aborted by a transformer, for
+// the fire paths that cannot return it.
+func (evsw *eventSwitch) logTransformError(event string, err error) {
+	if !errors.Is(err, errFireRejected) {
+		evsw.logger.Error("event transformer failed", "event", event, "err", err)
+	}
+}
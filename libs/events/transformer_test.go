@@ -0,0 +1,113 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestAddTransformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	evsw.AddTransformer("tx", func(data EventData) (EventData, error) {
+		return fmt.Sprintf("%v@height=7", data), nil
+	})
+	evsw.AddTransformer("tx", func(data EventData) (EventData, error) {
+		return fmt.Sprintf("[%v]", data), nil
+	})
+	evsw.AddTransformer("tx", nil)
+
+	var received []EventData
+	record := func(_ context.Context, data EventData) error {
+		received = append(received, data)
+		return nil
+	}
+	require.NoError(t, evsw.AddListenerForEvent("first", "tx", record))
+	require.NoError(t, evsw.AddListenerForEvent("second", "tx", record))
+	require.NoError(t, evsw.AddListenerForEvent("first", "other", record))
+
+	evsw.FireEvent(ctx, "tx", "transfer")
+	evsw.FireEvent(ctx, "other", "untouched")
+	assert.Equal(t, []EventData{"[transfer@height=7]", "[transfer@height=7]", "untouched"}, received)
+
+	// the other fire paths transform too
+	received = nil
+	delivered, _ := evsw.FireEventCounted(ctx, "tx", "swap")
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []EventData{"[swap@height=7]", "[swap@height=7]"}, received)
+}
+
+func TestAddTransformerError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	evsw.AddTransformer("tx", func(data EventData) (EventData, error) {
+		if data == "invalid" {
+			return nil, errors.New("cannot enrich")
+		}
+		return data, nil
+	})
+	later := false
+	evsw.AddTransformer("tx", func(data EventData) (EventData, error) {
+		later = true
+		return data, nil
+	})
+
+	var received []EventData
+	require.NoError(t, evsw.AddListenerForEvent("listener", "tx",
+		func(_ context.Context, data EventData) error {
+			received = append(received, data)
+			return nil
+		}))
+
+	evsw.FireEvent(ctx, "tx", "invalid")
+	assert.Empty(t, received, "a failed transformation aborts the fire")
+	assert.False(t, later, "the later transformers do not run")
+
+	evsw.FireEvent(ctx, "tx", "valid")
+	assert.Equal(t, []EventData{"valid"}, received)
+}
+
+func TestAddTransformerErrorReturned(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	errEnrich := errors.New("cannot enrich")
+	evsw.AddTransformer("tx", func(data EventData) (EventData, error) {
+		return nil, errEnrich
+	})
+
+	called := false
+	require.NoError(t, evsw.AddListenerForEvent("listener", "tx",
+		func(context.Context, EventData) error {
+			called = true
+			return nil
+		}))
+
+	err := evsw.FireEventParallel(ctx, "tx", "transfer")
+	assert.ErrorIs(t, err, errEnrich, "FireEventParallel")
+	err = evsw.FireEventFirstSuccess(ctx, "tx", "transfer")
+	assert.ErrorIs(t, err, errEnrich, "FireEventFirstSuccess")
+	err = evsw.FireEventAwait(ctx, "tx", "transfer", "listener")
+	assert.ErrorIs(t, err, errEnrich, "FireEventAwait")
+	assert.False(t, called)
+}
@@ -0,0 +1,104 @@
+package events
+
+import "errors"
+
+// ErrTxDone is returned by Tx.Commit when the transaction was already
+// committed.
+var ErrTxDone = errors.New("transaction already committed")
+
+// Tx is a transaction batching subscriptions and unsubscriptions, see
+// EventSwitch.Begin. Its changes are applied atomically on Commit: every
+// fire reaches either the listeners before the transaction or those after
+// it, never a mix of both, so a set of listeners can be swapped for another
+// without a fire reaching neither or both. A Tx must not be used
+// concurrently.
+type Tx struct {
+	evsw *eventSwitch
+	ops  []txOp
+	done bool
+}
+
+type txOp struct {
+	key    subKey
+	cb     EventCallback
+	remove bool
+}
+
+func (evsw *eventSwitch) Begin() *Tx {
+	return &Tx{evsw: evsw}
+}
+
+// Add subscribes the listener to event with cb on Commit, like
+// AddListenerForEvent.
+func (tx *Tx) Add(listenerID, event string, cb EventCallback) {
+	tx.ops = append(tx.ops, txOp{key: subKey{listenerID: listenerID, event: event}, cb: cb})
+}
+
+// Remove unsubscribes the listener from event on Commit, like
+// RemoveListenerForEvent.
+func (tx *Tx) Remove(listenerID, event string) {
+	tx.ops = append(tx.ops, txOp{key: subKey{listenerID: listenerID, event: event}, remove: true})
+}
+
+// Commit applies the changes of the transaction in the order they were
+// made. If one of the callbacks added is nil, it returns ErrNilCallback and
+// applies none of them.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	for _, op := range tx.ops {
+		if !op.remove && op.cb == nil {
+			return ErrNilCallback
+		}
+	}
+	tx.done = true
+
+	evsw := tx.evsw
+	touched := make(map[string]*eventCell)
+	evsw.mtx.Lock()
+	for _, op := range tx.ops {
+		eventCell := evsw.eventCells[op.key.event]
+		if op.remove {
+			if eventCell != nil {
+				eventCell.RemoveListener(op.key.listenerID)
+				touched[op.key.event] = eventCell
+			}
+			continue
+		}
+
+		if eventCell == nil {
+			eventCell = newEventCell()
+			evsw.eventCells[op.key.event] = eventCell
+		}
+		listener := evsw.listeners[op.key.listenerID]
+		if listener == nil {
+			listener = newEventListener(op.key.listenerID)
+			evsw.listeners[op.key.listenerID] = listener
+		}
+		if err := listener.AddEvent(op.key.event); err != nil {
+			// The listener is being removed concurrently; the
+			// subscription starts a listener afresh.
+			listener = newEventListener(op.key.listenerID)
+			_ = listener.AddEvent(op.key.event)
+			evsw.listeners[op.key.listenerID] = listener
+		}
+		eventCell.AddListener(listener, op.cb)
+	}
+	// Garbage collect the cells left empty.
+	for event, eventCell := range touched {
+		eventCell.mtx.RLock()
+		if len(eventCell.listeners) == 0 && evsw.eventCells[event] == eventCell {
+			delete(evsw.eventCells, event)
+		}
+		eventCell.mtx.RUnlock()
+	}
+	evsw.mtx.Unlock()
+
+	// As for the individual calls, replaced and removed subscriptions release
+	// their channels and batches once the switch is unlocked.
+	for _, op := range tx.ops {
+		evsw.detach(op.key)
+	}
+	return nil
+}
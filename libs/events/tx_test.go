@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestTx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var received []string
+	record := func(listenerID string) EventCallback {
+		return func(context.Context, EventData) error {
+			received = append(received, listenerID)
+			return nil
+		}
+	}
+	require.NoError(t, evsw.AddListenerForEvent("old", "event", record("old")))
+
+	tx := evsw.Begin()
+	tx.Remove("old", "event")
+	tx.Add("new", "event", record("new"))
+	tx.Add("new", "other", record("new"))
+
+	// nothing is applied before Commit
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"old"}, received)
+
+	require.NoError(t, tx.Commit())
+	require.ErrorIs(t, tx.Commit(), ErrTxDone)
+	assert.Equal(t, []string{"event", "other"}, evsw.EventNames())
+	assert.Equal(t, []string{"new"}, evsw.Listeners("event"))
+
+	received = nil
+	evsw.FireEvent(ctx, "event", nil)
+	assert.Equal(t, []string{"new"}, received)
+
+	// an invalid transaction applies nothing
+	tx = evsw.Begin()
+	tx.Remove("new", "event")
+	tx.Add("bad", "event", nil)
+	require.ErrorIs(t, tx.Commit(), ErrNilCallback)
+	assert.Equal(t, []string{"new"}, evsw.Listeners("event"))
+
+	// emptied cells are garbage collected
+	tx = evsw.Begin()
+	tx.Remove("new", "other")
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []string{"event"}, evsw.EventNames())
+}
+
+func TestTxConcurrentFires(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	var mtx sync.Mutex
+	reached := make(map[int64][]string)
+	record := func(listenerID string) EventCallback {
+		return func(_ context.Context, data EventData) error {
+			mtx.Lock()
+			reached[data.(int64)] = append(reached[data.(int64)], listenerID)
+			mtx.Unlock()
+			return nil
+		}
+	}
+	sets := [][]string{{"a1", "a2", "a3"}, {"b1", "b2", "b3"}}
+	for _, listenerID := range sets[0] {
+		require.NoError(t, evsw.AddListenerForEvent(listenerID, "event", record(listenerID)))
+	}
+
+	var seq int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					evsw.FireEvent(ctx, "event", atomic.AddInt64(&seq, 1))
+				}
+			}
+		}()
+	}
+
+	// swap the sets back and forth while firing
+	for i := 0; i < 200 || atomic.LoadInt64(&seq) < 2000; i++ {
+		from, to := sets[i%2], sets[(i+1)%2]
+		tx := evsw.Begin()
+		for _, listenerID := range from {
+			tx.Remove(listenerID, "event")
+		}
+		for _, listenerID := range to {
+			tx.Add(listenerID, "event", record(listenerID))
+		}
+		require.NoError(t, tx.Commit())
+	}
+	close(stop)
+	wg.Wait()
+
+	// every fire reached one of the sets
+	fires := int(atomic.LoadInt64(&seq))
+	require.Len(t, reached, fires)
+	for fire := int64(1); fire <= int64(fires); fire++ {
+		if _, ok := reached[fire]; !ok {
+			t.Fatalf("fire %d reached no listener", fire)
+		}
+	}
+	for fire, listeners := range reached {
+		sort.Strings(listeners)
+		got := strings.Join(listeners, ",")
+		if got != "a1,a2,a3" && got != "b1,b2,b3" {
+			t.Fatalf("fire %d reached a partial set: %s", fire, got)
+		}
+	}
+}
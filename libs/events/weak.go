@@ -0,0 +1,97 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// ErrInvalidOwner is returned by AddWeakListener if the owner is not a
+// non-nil pointer to a value of non-zero size.
+var ErrInvalidOwner = errors.New("weak listener owner must be a non-nil pointer to a value of non-zero size")
+
+// weakListeners generates the IDs of the listeners registered by
+// AddWeakListener.
+type weakListeners struct {
+	mtx    sync.Mutex
+	nextID uint64
+}
+
+// weakOwners tracks the owners of weak listeners across all switches, keyed
+// by their address, and the listeners each of them has on every switch. An
+// object can only have a single finalizer, and setting a second one is a
+// fatal error, so an owner gets its finalizer when it is first registered on
+// any switch and the finalizer removes its listeners from all of them. The
+// address is stored as an integer so the owner itself is not kept alive.
+var weakOwners struct {
+	mtx    sync.Mutex
+	owners map[uintptr]map[*eventSwitch][]string
+}
+
+// AddWeakListener subscribes cb to event on behalf of owner, which must be a
+// non-nil pointer to a value of non-zero size, and returns the generated
+// listener ID. The listener is removed once owner becomes unreachable and is
+// garbage collected. The same owner may have weak listeners on several
+// switches.
+//
+// The removal is driven by a finalizer set on owner, with the usual caveats:
+// it runs at some unspecified point after the owner becomes unreachable, or
+// not at all if the program exits first; it is never run if cb (or anything
+// else reachable from the switch) references owner; and owner must point to
+// the beginning of an allocated object that has no finalizer of its own,
+// which the runtime gives no way to check. Calling RemoveListener with the
+// returned ID removes the listener early.
+func (evsw *eventSwitch) AddWeakListener(owner interface{}, event string, cb EventCallback) (string, error) {
+	if cb == nil {
+		return "", ErrNilCallback
+	}
+	v := reflect.ValueOf(owner)
+	// Values of zero size all share one address and are never collected.
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Type().Elem().Size() == 0 {
+		return "", ErrInvalidOwner
+	}
+	addr := v.Pointer()
+
+	evsw.weak.mtx.Lock()
+	evsw.weak.nextID++
+	listenerID := fmt.Sprintf("weak#%d", evsw.weak.nextID)
+	evsw.weak.mtx.Unlock()
+
+	if err := evsw.AddListenerForEvent(listenerID, event, cb); err != nil {
+		return "", err
+	}
+
+	weakOwners.mtx.Lock()
+	defer weakOwners.mtx.Unlock()
+
+	if weakOwners.owners == nil {
+		weakOwners.owners = make(map[uintptr]map[*eventSwitch][]string)
+	}
+	switches, ok := weakOwners.owners[addr]
+	if !ok {
+		switches = make(map[*eventSwitch][]string)
+		weakOwners.owners[addr] = switches
+		runtime.SetFinalizer(owner, func(interface{}) {
+			removeWeakListeners(addr)
+		})
+	}
+	switches[evsw] = append(switches[evsw], listenerID)
+	return listenerID, nil
+}
+
+// removeWeakListeners removes the listeners of the owner at addr from every
+// switch.
+func removeWeakListeners(addr uintptr) {
+	weakOwners.mtx.Lock()
+	switches := weakOwners.owners[addr]
+	delete(weakOwners.owners, addr)
+	weakOwners.mtx.Unlock()
+
+	for evsw, listenerIDs := range switches {
+		for _, listenerID := range listenerIDs {
+			evsw.RemoveListener(listenerID)
+		}
+	}
+}
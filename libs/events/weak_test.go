@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// weakOwner is large enough not to be batched by the tiny allocator, which
+// would delay its finalizer indefinitely.
+type weakOwner struct {
+	_ [64]byte
+}
+
+func TestAddWeakListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evsw := NewEventSwitch(log.TestingLogger())
+	require.NoError(t, evsw.Start(ctx))
+	t.Cleanup(evsw.Wait)
+
+	received := make(chan EventData, 10)
+	cb := func(_ context.Context, data EventData) error {
+		received <- data
+		return nil
+	}
+
+	func() {
+		owner := &weakOwner{}
+		_, err := evsw.AddWeakListener(owner, "event", cb)
+		require.NoError(t, err)
+		_, err = evsw.AddWeakListener(owner, "other", cb)
+		require.NoError(t, err)
+
+		evsw.FireEvent(ctx, "event", 1)
+		assert.Equal(t, 1, <-received)
+		runtime.KeepAlive(owner)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for hasListeners(evsw) {
+		if time.Now().After(deadline) {
+			t.Fatal("weak listeners were not removed after the owner was collected")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	evsw.FireEvent(ctx, "event", 2)
+	evsw.FireEvent(ctx, "other", 3)
+	assert.Empty(t, received)
+}
+
+func TestAddWeakListenerSeveralSwitches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	switches := make([]EventSwitch, 2)
+	for i := range switches {
+		switches[i] = NewEventSwitch(log.TestingLogger())
+		require.NoError(t, switches[i].Start(ctx))
+		t.Cleanup(switches[i].Wait)
+	}
+
+	received := make(chan EventData, 10)
+	cb := func(_ context.Context, data EventData) error {
+		received <- data
+		return nil
+	}
+
+	func() {
+		owner := &weakOwner{}
+		for _, evsw := range switches {
+			_, err := evsw.AddWeakListener(owner, "event", cb)
+			require.NoError(t, err)
+		}
+		for i, evsw := range switches {
+			evsw.FireEvent(ctx, "event", i)
+			assert.Equal(t, i, <-received)
+		}
+		runtime.KeepAlive(owner)
+	}()
+
+	// the single finalizer of the owner removes its listeners from both
+	deadline := time.Now().Add(5 * time.Second)
+	for hasListeners(switches[0]) || hasListeners(switches[1]) {
+		if time.Now().After(deadline) {
+			t.Fatal("weak listeners were not removed after the owner was collected")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAddWeakListenerInvalidOwner(t *testing.T) {
+	evsw := NewEventSwitch(log.TestingLogger())
+	noop := func(context.Context, EventData) error { return nil }
+
+	var nilOwner *weakOwner
+	for _, owner := range []interface{}{nil, weakOwner{}, nilOwner, &struct{}{}} {
+		_, err := evsw.AddWeakListener(owner, "event", noop)
+		assert.ErrorIs(t, err, ErrInvalidOwner)
+	}
+}
+
+// hasListeners reports whether any event of evsw has listeners.
+func hasListeners(evsw EventSwitch) bool {
+	found := false
+	evsw.RangeEvents(func(string, []string) bool {
+		found = true
+		return false
+	})
+	return found
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	tmevents "github.com/tendermint/tendermint/libs/events"
 )
 
 func TestQueryTxFor(t *testing.T) {
@@ -25,3 +27,11 @@ func TestQueryForEvent(t *testing.T) {
 		QueryForEvent(EventNewEvidenceValue).String(),
 	)
 }
+
+// The consensus reactor fires and subscribes to these events on its event
+// switch under the names of libs/events, which must match the event values.
+func TestEventSwitchNames(t *testing.T) {
+	assert.Equal(t, EventNewRoundStepValue, tmevents.EventNewRoundStep.String())
+	assert.Equal(t, EventValidBlockValue, tmevents.EventValidBlock.String())
+	assert.Equal(t, EventVoteValue, tmevents.EventVote.String())
+}